@@ -7,27 +7,13 @@ import (
 	"sort"
 )
 
-// StatsProcedure is the result of "@Statistics PROCEDURE"
+// StatsProcedure is the subset of "@Statistics PROCEDURE" columns
+// used by voltmeter. Other columns are ignored.
 type StatsProcedure struct {
-	Timestamp        int64
-	HostId           int64
-	Hostname         string
-	SiteId           int64
-	PartitionId      int64
 	Procedure        string
 	Invocations      int64
-	TimedInvocations int64
-	MinExecTime      int64
-	MaxExecTime      int64
-	AvgExecTime      int64
-	MinResultSize    int64
-	MaxResultSize    int64
-	AvgResultSize    int64
-	MinParamSetSize  int64
-	MaxParamSetSize  int64
-	AvgParamSetSize  int64
-	Aborts           int64
-	Failures         int64
+	TimedInvocations int64 `volt:"TIMED_INVOCATIONS"`
+	AvgExecTime      int64 `volt:"AVG_EXECUTION_TIME"`
 }
 
 func (p StatsProcedure) weight() int64 {
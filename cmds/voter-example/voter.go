@@ -21,11 +21,6 @@ var voterGoroutines = 8
 var votingDuration = 15 * time.Second
 var cpuprofile = ""
 
-// ScalarResult is used to read scalar stored procedures results.
-type ScalarResult struct {
-	Result int
-}
-
 func main() {
 	flag.DurationVar(&votingDuration, "duration", 15*time.Second, "seconds to execute")
 	flag.StringVar(&cpuprofile, "cpuprofile", "", "name of profile file to write")
@@ -73,9 +68,11 @@ func initialize(volt *voltdb.Conn) {
 		log.Fatalf("Failed in initialize database. %v\n", err)
 	}
 	if rsp.Status() == voltdb.SUCCESS {
-		var rs ScalarResult
-		rsp.Table(0).Next(&rs)
-		fmt.Printf("Initialized %d contestants.\n", rs.Result)
+		count, err := voltdb.Scalar[int](rsp.Table(0))
+		if err != nil {
+			log.Fatalf("Failed to read initialize result. %v\n", err)
+		}
+		fmt.Printf("Initialized %d contestants.\n", count)
 	} else {
 		log.Fatalf("Failed to initialize %#v.\n", rsp)
 	}
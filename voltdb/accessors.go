@@ -0,0 +1,120 @@
+package voltdb
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"reflect"
+	"time"
+)
+
+// accessors.go reads single cells of a Row without declaring a struct.
+// A column is given by its index or by its name, matched as ColumnIndex
+// does. Values convert as they do for struct fields, so GetInt64 reads
+// any integer column but fails for a VARCHAR, and NULL reads as the
+// zero value; IsNull tells NULL apart. A NULL TIMESTAMP read by GetInt64
+// is an error, as 0 is the epoch.
+
+// GetInt64 returns the value of an integer column.
+func (row *Row) GetInt64(column interface{}) (int64, error) {
+	return getCell[int64](row, column)
+}
+
+// GetInt32 returns the value of an integer column that fits an int32.
+func (row *Row) GetInt32(column interface{}) (int32, error) {
+	return getCell[int32](row, column)
+}
+
+// GetFloat64 returns the value of a numeric column.
+func (row *Row) GetFloat64(column interface{}) (float64, error) {
+	return getCell[float64](row, column)
+}
+
+// GetBool returns the value of a BOOLEAN or integer column.
+func (row *Row) GetBool(column interface{}) (bool, error) {
+	return getCell[bool](row, column)
+}
+
+// GetString returns the value of any column as a string.
+func (row *Row) GetString(column interface{}) (string, error) {
+	return getCell[string](row, column)
+}
+
+// GetBytes returns the value of a VARBINARY or VARCHAR column.
+func (row *Row) GetBytes(column interface{}) ([]byte, error) {
+	return getCell[[]byte](row, column)
+}
+
+// GetTime returns the value of a TIMESTAMP column.
+func (row *Row) GetTime(column interface{}) (time.Time, error) {
+	return getCell[time.Time](row, column)
+}
+
+// GetDecimal returns the value of a DECIMAL column, or nil for NULL.
+func (row *Row) GetDecimal(column interface{}) (*big.Rat, error) {
+	return getCell[*big.Rat](row, column)
+}
+
+// GetValue returns the value of a column as NextValues does.
+func (row *Row) GetValue(column interface{}) (interface{}, error) {
+	return getCell[interface{}](row, column)
+}
+
+// IsNull reports whether the value of a column is NULL.
+func (row *Row) IsNull(column interface{}) (bool, error) {
+	idx, r, err := row.cell(column)
+	if err != nil {
+		return false, err
+	}
+	raw, err := readRaw(r, row.table.columnTypes[idx])
+	return raw == nil, err
+}
+
+// getCell decodes a column of row into a T.
+func getCell[T any](row *Row, column interface{}) (T, error) {
+	var val T
+	idx, r, err := row.cell(column)
+	if err != nil {
+		return val, err
+	}
+	name := row.table.columnNames[idx]
+	field := reflect.StructField{Name: "value", Type: reflect.TypeOf(&val).Elem()}
+	decode, err := fieldDecoder(name, row.table.columnTypes[idx], field, row.table.location)
+	if err != nil {
+		return val, err
+	}
+	err = decode(r, reflect.ValueOf(&val).Elem())
+	return val, err
+}
+
+// cell returns the index of column and a reader positioned at its value.
+func (row *Row) cell(column interface{}) (int, *bytes.Buffer, error) {
+	idx := -1
+	switch c := column.(type) {
+	case int:
+		idx = c
+	case string:
+		if idx = row.table.ColumnIndex(c); idx < 0 {
+			return 0, nil, fmt.Errorf("No column %v.", c)
+		}
+	default:
+		return 0, nil, fmt.Errorf("Column must be an int index or a string name, have %T.", column)
+	}
+	if idx < 0 || idx >= len(row.table.columnTypes) {
+		return 0, nil, fmt.Errorf("Column %d out of range [0, %d).", idx, len(row.table.columnTypes))
+	}
+
+	if row.offsets == nil {
+		// skip the length header, then each value.
+		r := bytes.NewBuffer(row.raw[4:])
+		offsets := make([]int, len(row.table.columnTypes))
+		for col, vt := range row.table.columnTypes {
+			offsets[col] = len(row.raw) - r.Len()
+			if err := skipValue(r, vt); err != nil {
+				return 0, nil, err
+			}
+		}
+		row.offsets = offsets
+	}
+	return idx, bytes.NewBuffer(row.raw[row.offsets[idx]:]), nil
+}
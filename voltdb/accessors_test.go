@@ -0,0 +1,60 @@
+package voltdb
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRowAccessors(t *testing.T) {
+	when := time.Unix(1400000000, 0)
+	table := testTable(
+		[]string{"ID", "NAME", "VOTES", "AT", "EMPTY"},
+		[]int8{vt_INT, vt_STRING, vt_LONG, vt_TIMESTAMP, vt_STRING},
+		[]interface{}{int32(1), "one", int64(nullBigInt), when, ""})
+
+	row, err := table.NextRow()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if row.Index() != 0 || table.HasNext() {
+		t.Errorf("Expected NextRow to consume row 0")
+	}
+	if id, err := row.GetInt64("id"); err != nil || id != 1 {
+		t.Errorf("Bad ID %v: %v", id, err)
+	}
+	if name, err := row.GetString(1); err != nil || name != "one" {
+		t.Errorf("Bad NAME %v: %v", name, err)
+	}
+	if at, err := row.GetTime("AT"); err != nil || !at.Equal(when) {
+		t.Errorf("Bad AT %v: %v", at, err)
+	}
+	if id, err := row.GetString("ID"); err != nil || id != "1" {
+		t.Errorf("Bad ID as string %v: %v", id, err)
+	}
+	if votes, err := row.GetInt64("VOTES"); err != nil || votes != 0 {
+		t.Errorf("Bad NULL VOTES %v: %v", votes, err)
+	}
+	if val, err := row.GetValue("VOTES"); err != nil || val != nil {
+		t.Errorf("Bad NULL VOTES value %v: %v", val, err)
+	}
+	for column, expected := range map[string]bool{"VOTES": true, "EMPTY": false, "NAME": false} {
+		if null, err := row.IsNull(column); err != nil || null != expected {
+			t.Errorf("Bad IsNull(%v) %v: %v", column, null, err)
+		}
+	}
+
+	if _, err := row.GetInt64("NAME"); err == nil {
+		t.Errorf("Expected error reading VARCHAR as int64")
+	}
+	if _, err := row.GetTime("ID"); err == nil {
+		t.Errorf("Expected error reading INTEGER as time")
+	}
+	for _, column := range []interface{}{"NOPE", 5, -1, 1.5} {
+		if _, err := row.GetString(column); err == nil {
+			t.Errorf("Expected error reading column %v", column)
+		}
+	}
+	if _, err := table.NextRow(); err == nil {
+		t.Errorf("Expected error reading past the last row")
+	}
+}
@@ -2,14 +2,40 @@ package voltdb
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
 	"fmt"
 	"net"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
-// Conn is a single connection to a single node of a VoltDB database
+// Conn is a single connection to a single node of a VoltDB database.
+// A Conn may be used by multiple goroutines; calls are pipelined over
+// the network connection and responses matched by client handle.
 type Conn struct {
-	tcpConn  *net.TCPConn
-	connData *connectionData
+	cfg     *Config
+	writeMu sync.Mutex // serializes message writes
+
+	mu         sync.Mutex // guards the fields below
+	netConn    net.Conn
+	connData   *connectionData
+	closed     bool
+	pending    map[int64]*pendingCall
+	nextHandle int64
+
+	// reconnecting is closed when a reconnect in progress finishes, with
+	// its result in reconnectErr.
+	reconnecting chan struct{}
+	reconnectErr error
+
+	// signatures holds the parameters of each procedure, by upper case
+	// name, read by ParamsFromMap.
+	signatures map[string][]procedureColumn
+
+	lastRead atomic.Int64 // UnixNano of the last message received
 }
 
 // connectionData are the values returned by a successful login.
@@ -20,45 +46,262 @@ type connectionData struct {
 	buildString string
 }
 
+// Dialer opens the network connection to a VoltDB node. It has the
+// signature of net.Dialer.DialContext so that any transport (a SOCKS
+// or SSH tunnel, a unix socket, an in-memory net.Pipe) can be used.
+type Dialer func(ctx context.Context, network, addr string) (net.Conn, error)
+
 // NewConn creates an initialized, authenticated Conn.
 func NewConnection(user string, passwd string, hostAndPort string) (*Conn, error) {
-	var conn = new(Conn)
-	var err error
-	var raddr *net.TCPAddr
-	var login bytes.Buffer
+	return Dial(&Config{Addresses: []string{hostAndPort}, User: user, Password: passwd})
+}
+
+// NewConnectionWithDialer creates an initialized, authenticated Conn
+// using dialer to open the network connection. A nil dialer uses
+// net.Dialer. The dialer is invoked with network "tcp" and hostAndPort.
+func NewConnectionWithDialer(ctx context.Context, dialer Dialer,
+	user string, passwd string, hostAndPort string) (*Conn, error) {
+	return DialContext(ctx, &Config{Addresses: []string{hostAndPort},
+		User: user, Password: passwd, Dialer: dialer})
+}
+
+// Dial creates an initialized, authenticated Conn to the first of
+// cfg.Addresses that accepts a login.
+func Dial(cfg *Config) (*Conn, error) {
+	return DialContext(context.Background(), cfg)
+}
 
-	if raddr, err = net.ResolveTCPAddr("tcp", hostAndPort); err != nil {
-		return nil, fmt.Errorf("Error resolving %v.", hostAndPort)
+// DialWithOptions creates a Conn from a Config built by opts.
+func DialWithOptions(opts ...Option) (*Conn, error) {
+	var cfg Config
+	for _, opt := range opts {
+		opt(&cfg)
 	}
-	if conn.tcpConn, err = net.DialTCP("tcp", nil, raddr); err != nil {
+	return Dial(&cfg)
+}
+
+// DialContext is Dial with a context that bounds connecting.
+func DialContext(ctx context.Context, cfg *Config) (*Conn, error) {
+	cfg, err := cfg.withDefaults()
+	if err != nil {
 		return nil, err
 	}
-	if login, err = serializeLoginMessage(user, passwd); err != nil {
+	conn := &Conn{cfg: cfg}
+	if err = conn.connect(ctx); err != nil {
 		return nil, err
 	}
-	if err = conn.writeMessage(login); err != nil {
-		return nil, err
+	return conn, nil
+}
+
+// connect tries each configured address in turn and keeps the first
+// authenticated network connection.
+func (conn *Conn) connect(ctx context.Context) (err error) {
+	for _, addr := range conn.cfg.Addresses {
+		if err = conn.connectTo(ctx, addr); err == nil {
+			conn.logf("connected to %v", addr)
+			return nil
+		}
+		conn.logf("failed to connect to %v: %v", addr, err)
 	}
-	if conn.connData, err = conn.readLoginResponse(); err != nil {
-		return nil, err
+	return err
+}
+
+func (conn *Conn) connectTo(ctx context.Context, addr string) error {
+	cfg := conn.cfg
+	if cfg.DialTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.DialTimeout)
+		defer cancel()
+	}
+	dialer := cfg.Dialer
+	if dialer == nil {
+		d := net.Dialer{KeepAlive: cfg.KeepAlive}
+		dialer = d.DialContext
+	}
+	netConn, err := dialer(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	if cfg.TLS != nil {
+		tlsConfig := cfg.TLS
+		if tlsConfig.ServerName == "" && !tlsConfig.InsecureSkipVerify {
+			tlsConfig = tlsConfig.Clone()
+			tlsConfig.ServerName, _, _ = splitHostPort(addr)
+		}
+		tlsConn := tls.Client(netConn, tlsConfig)
+		if err = tlsConn.HandshakeContext(ctx); err != nil {
+			netConn.Close()
+			return err
+		}
+		netConn = tlsConn
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		netConn.SetDeadline(deadline)
+		defer netConn.SetDeadline(time.Time{})
+	}
+	if err = conn.login(netConn); err != nil {
+		netConn.Close()
+		return err
+	}
+	return nil
+}
+
+// login authenticates over an established netConn and, on success,
+// makes it the connection's transport.
+func (conn *Conn) login(netConn net.Conn) error {
+	var err error
+	var login bytes.Buffer
+	var connData *connectionData
+
+	if login, err = serializeLoginMessage(conn.cfg.User, conn.cfg.Password,
+		conn.cfg.ServiceName, conn.cfg.HashScheme); err != nil {
+		return err
+	}
+	if err = conn.writeMessage(netConn, conn.cfg.ProtocolVersion, login); err != nil {
+		return err
+	}
+	if connData, err = conn.readLoginResponse(netConn); err != nil {
+		return err
+	}
+
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	if conn.closed {
+		return errClosed()
+	}
+	conn.netConn = netConn
+	conn.connData = connData
+	conn.signatures = nil
+	conn.lastRead.Store(time.Now().UnixNano())
+	go conn.readLoop(netConn)
+	if conn.cfg.HeartbeatInterval > 0 {
+		go conn.heartbeat(netConn)
+	}
+	return nil
+}
+
+func errClosed() error {
+	return fmt.Errorf("Can not call procedure on closed Conn.")
+}
+
+// reconnect replaces a lost network connection according to the
+// configured ReconnectPolicy. Only one reconnect runs at a time; other
+// callers wait for its result. conn.mu is not held while sleeping or
+// dialing, and Close stops the attempts. The caller must not hold
+// conn.mu.
+func (conn *Conn) reconnect() error {
+	conn.mu.Lock()
+	if conn.closed {
+		conn.mu.Unlock()
+		return errClosed()
+	}
+	if conn.netConn != nil {
+		conn.mu.Unlock()
+		return nil
+	}
+	if done := conn.reconnecting; done != nil {
+		conn.mu.Unlock()
+		<-done
+		conn.mu.Lock()
+		defer conn.mu.Unlock()
+		if conn.netConn != nil {
+			return nil
+		}
+		if conn.closed || conn.reconnectErr == nil {
+			return errClosed()
+		}
+		return conn.reconnectErr
+	}
+	done := make(chan struct{})
+	conn.reconnecting = done
+	conn.mu.Unlock()
+
+	err := conn.redial()
+
+	conn.mu.Lock()
+	conn.reconnecting = nil
+	conn.reconnectErr = err
+	conn.mu.Unlock()
+	close(done)
+	return err
+}
+
+// redial makes the attempts of the ReconnectPolicy until one connects
+// or the Conn is closed.
+func (conn *Conn) redial() error {
+	policy := conn.cfg.Reconnect
+	err := errClosed()
+	for attempt := 0; attempt < policy.MaxAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(policy.Interval)
+		}
+		if conn.isClosed() {
+			return errClosed()
+		}
+		conn.logf("reconnecting, attempt %d of %d", attempt+1, policy.MaxAttempts)
+		if err = conn.connect(context.Background()); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func (conn *Conn) isClosed() bool {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	return conn.closed
+}
+
+// connectionLost discards netConn after it failed with err and fails
+// the calls waiting on it. A lost connection may be re-established by
+// a later Call if reconnecting is enabled.
+func (conn *Conn) connectionLost(netConn net.Conn, err error) {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	if conn.netConn == netConn {
+		if !conn.closed {
+			conn.logf("connection lost: %v", err)
+		}
+		conn.netConn = nil
+		conn.connData = nil
+	}
+	netConn.Close()
+	if conn.closed {
+		err = fmt.Errorf("Conn closed while waiting for response.")
+	}
+	for handle, call := range conn.pending {
+		if call.netConn == netConn {
+			delete(conn.pending, handle)
+			call.result <- callResult{err: err}
+		}
+	}
+}
+
+func (conn *Conn) logf(format string, v ...interface{}) {
+	if conn.cfg != nil && conn.cfg.Logger != nil {
+		conn.cfg.Logger.Printf("voltdb: "+format, v...)
 	}
-	return conn, nil
 }
 
 // Close a connection if open. A Conn, once closed, has no further use.
 // To open a new connection, use NewConnection.
 func (conn *Conn) Close() error {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
 	var err error = nil
-	if conn.tcpConn != nil {
-		err = conn.tcpConn.Close()
+	if conn.netConn != nil {
+		err = conn.netConn.Close()
 	}
-	conn.tcpConn = nil
+	conn.netConn = nil
 	conn.connData = nil
+	conn.closed = true
 	return err
 }
 
 // GoString provides a default printable format for Conn.
 func (conn *Conn) GoString() string {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
 	if conn.connData != nil {
 		return fmt.Sprintf("hostId:%v, connId:%v, leaderAddr:%v buildString:%v",
 			conn.connData.hostId, conn.connData.connId,
@@ -69,9 +312,6 @@ func (conn *Conn) GoString() string {
 
 // Ping the database for liveness.
 func (conn *Conn) TestConnection() bool {
-	if conn.tcpConn == nil {
-		return false
-	}
 	rsp, err := conn.Call("@Ping")
 	if err != nil {
 		return false
@@ -80,27 +320,144 @@ func (conn *Conn) TestConnection() bool {
 }
 
 // Call invokes the procedure 'procedure' with parameter values 'params'
-// and returns a pointer to the received Response.
+// and returns a pointer to the received Response. CallOption values,
+// such as WithTimeout, may be mixed into params to control the call.
 func (conn *Conn) Call(procedure string, params ...interface{}) (*Response, error) {
+	params, opts, err := splitCallOptions(params)
+	if err != nil {
+		return nil, err
+	}
+	timeout := opts.timeout
+	if timeout == 0 && conn.cfg != nil {
+		timeout = conn.cfg.ReadTimeout
+	}
+	if conn.cfg != nil {
+		opts.precision = conn.cfg.TimestampPrecision
+	}
+	buf, _, err := conn.invoke(procedure, params, opts, timeout)
+	if err != nil {
+		return nil, err
+	}
+	rsp, err := deserializeCallResponse(buf)
+	if err != nil {
+		return nil, err
+	}
+	if conn.cfg != nil {
+		for idx := range rsp.tables {
+			rsp.tables[idx].location = conn.cfg.Location
+		}
+	}
+	return rsp, nil
+}
+
+// invoke sends an invocation and waits up to timeout (if positive) for
+// the response message. It returns the network connection used.
+func (conn *Conn) invoke(procedure string, params []interface{}, opts callOptions,
+	timeout time.Duration) (*bytes.Buffer, net.Conn, error) {
 	var call bytes.Buffer
-	var resp *bytes.Buffer
 	var err error
 
-	if conn.tcpConn == nil {
-		return nil, fmt.Errorf("Can not call procedure on closed Conn.")
+	netConn, pc, err := conn.register(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	if call, err = serializeCall(procedure, pc.handle, params, opts); err != nil {
+		conn.unregister(pc.handle)
+		return nil, netConn, err
 	}
 
-	// Use 0 for handle; it's not necessary in pure sync client.
-	if call, err = serializeCall(procedure, 0, params); err != nil {
-		return nil, err
+	conn.writeMu.Lock()
+	err = conn.writeMessage(netConn, invocationVersion(opts), call)
+	conn.writeMu.Unlock()
+	if err != nil {
+		conn.connectionLost(netConn, err)
+		return nil, netConn, err
 	}
-	if err := conn.writeMessage(call); err != nil {
-		return nil, err
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
 	}
-	if resp, err = conn.readMessage(); err != nil {
-		return nil, err
+	select {
+	case res := <-pc.result:
+		return res.buf, netConn, res.err
+	case <-expired:
+		conn.unregister(pc.handle)
+		return nil, netConn, fmt.Errorf("%w: %v did not respond within %v",
+			ErrTimeout, procedure, timeout)
+	}
+}
+
+// pendingCall is an invocation waiting for its response.
+type pendingCall struct {
+	handle  int64
+	netConn net.Conn
+	result  chan callResult
+}
+
+type callResult struct {
+	buf *bytes.Buffer
+	err error
+}
+
+// register assigns a client handle for a new invocation, connecting
+// first if the connection was lost and may be re-established.
+func (conn *Conn) register(opts callOptions) (net.Conn, *pendingCall, error) {
+	conn.mu.Lock()
+	if conn.netConn == nil && !conn.closed && conn.cfg != nil && conn.cfg.Reconnect.MaxAttempts > 0 {
+		conn.mu.Unlock()
+		if err := conn.reconnect(); err != nil {
+			return nil, nil, err
+		}
+		conn.mu.Lock()
+	}
+	defer conn.mu.Unlock()
+
+	if conn.netConn == nil {
+		return nil, nil, errClosed()
+	}
+	if conn.pending == nil {
+		conn.pending = make(map[int64]*pendingCall)
+	}
+
+	var handle int64
+	if opts.hasHandle {
+		handle = opts.handle
+		if _, ok := conn.pending[handle]; ok {
+			return nil, nil, fmt.Errorf("Client handle %d is already in use.", handle)
+		}
+	} else {
+		for {
+			conn.nextHandle++
+			handle = conn.nextHandle
+			if _, ok := conn.pending[handle]; !ok {
+				break
+			}
+		}
+	}
+	pc := &pendingCall{handle, conn.netConn, make(chan callResult, 1)}
+	conn.pending[handle] = pc
+	return conn.netConn, pc, nil
+}
+
+// unregister abandons a pending call. A late response is discarded.
+func (conn *Conn) unregister(handle int64) {
+	conn.mu.Lock()
+	delete(conn.pending, handle)
+	conn.mu.Unlock()
+}
+
+// dispatch delivers a response message to the call waiting on handle.
+func (conn *Conn) dispatch(handle int64, buf *bytes.Buffer) {
+	conn.mu.Lock()
+	pc, ok := conn.pending[handle]
+	delete(conn.pending, handle)
+	conn.mu.Unlock()
+	if ok {
+		pc.result <- callResult{buf: buf}
 	}
-	return deserializeCallResponse(resp)
 }
 
 // Response is a stored procedure result.
@@ -140,10 +497,13 @@ func (s Status) String() string {
 		return "UNEXPECTED FAILURE"
 	} else if s == CONNECTION_LOST {
 		return "CONNECTION LOST"
-	} else {
-		panic(fmt.Sprintf("Invalid status code: %d", int(s)))
 	}
-	return "unreachable"
+	return fmt.Sprintf("Status(%d)", int(s))
+}
+
+// ClientHandle returns the client handle the response answers.
+func (rsp *Response) ClientHandle() int64 {
+	return rsp.clientData
 }
 
 func (rsp *Response) Status() Status {
@@ -170,6 +530,10 @@ func (rsp *Response) ResultSets() []Table {
 	return rsp.tables
 }
 
+// Table returns the response's result table at offset. Every call
+// returns the same Table, sharing one read position, so rows read by
+// Next are not seen again through a later call. Use Table.Reset to
+// read the table again or Table.Cursor for an independent reader.
 func (rsp *Response) Table(offset int) *Table {
 	return &rsp.tables[offset]
 }
@@ -188,7 +552,16 @@ type Table struct {
 	columnTypes []int8
 	columnNames []string
 	rowCount    int32
-	rows        bytes.Buffer
+	data        []byte       // all row data, shared by cursors
+	rows        bytes.Buffer // the rows not yet read by this cursor
+	position    int          // index of the next row to read
+	index       *rowIndex    // row offsets, shared by cursors
+	strict      bool
+	location    *time.Location // of decoded timestamps; nil is UTC
+
+	// the decodePlan last used by Next, and the struct type it is for.
+	plan     *decodePlan
+	planType reflect.Type
 }
 
 func (table *Table) GoString() string {
@@ -205,6 +578,8 @@ func (table *Table) ColumnCount() int {
 	return int(table.columnCount)
 }
 
+// ColumnTypes returns the wire codes of the column types. Columns
+// returns them as ColumnTypes.
 func (table *Table) ColumnTypes() []int8 {
 	rv := make([]int8, 0)
 	rv = append(rv, table.columnTypes...)
@@ -222,11 +597,20 @@ func (table *Table) RowCount() int {
 	return int(table.rowCount)
 }
 
-// Next populates v (*struct) with the values of the next row.
+// Next populates v (*struct) with the values of the next row. Columns
+// are matched to fields by `volt:"COLUMN_NAME"` tags or, untagged, by
+// field name ignoring case. Unmatched columns are skipped and fields
+// tagged `volt:"-"` are left alone.
 func (table *Table) Next(v interface{}) error {
 	return table.next(v)
 }
 
+// SetStrict controls whether Next fails when a struct field has no
+// matching column. Tables are not strict by default.
+func (table *Table) SetStrict(strict bool) {
+	table.strict = strict
+}
+
 // HasNext returns true of there are additional rows to read.
 func (table *Table) HasNext() bool {
 	return table.rows.Len() > 0
@@ -2,31 +2,229 @@ package voltdb
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
+// serveLogin plays the server side of a login on conn.
+func serveLogin(conn net.Conn) error {
+	if _, err := readTestMessage(conn); err != nil {
+		return err
+	}
+	var rsp bytes.Buffer
+	writeByte(&rsp, 0)        // authentication result
+	writeInt(&rsp, 1)         // host id
+	writeLong(&rsp, 2)        // connection id
+	writeLong(&rsp, 0)        // cluster start timestamp
+	writeInt(&rsp, 0)         // leader address
+	writeString(&rsp, "test") // build string
+	return writeTestMessage(conn, rsp)
+}
+
+// pipeConn returns a Conn logged in over a net.Pipe and the server
+// end of the pipe.
+func pipeConn(t *testing.T, opts ...Option) (*Conn, net.Conn) {
+	client, server := net.Pipe()
+	dialer := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return client, nil
+	}
+	go serveLogin(server)
+	opts = append([]Option{WithAddresses("pipe:21212"), WithDialer(dialer)}, opts...)
+	conn, err := DialWithOptions(opts...)
+	if err != nil {
+		t.Fatalf("Unexpected connection error: %v", err)
+	}
+	return conn, server
+}
+
+// writeTestResponse answers handle with an empty successful response.
+func writeTestResponse(w io.Writer, handle int64) error {
+	var rsp bytes.Buffer
+	writeLong(&rsp, handle)
+	writeByte(&rsp, 0)  // fields present
+	writeByte(&rsp, 1)  // status
+	writeByte(&rsp, 0)  // app status
+	writeInt(&rsp, 0)   // cluster latency
+	writeShort(&rsp, 0) // result count
+	return writeTestMessage(w, rsp)
+}
+
+// serveCall reads one invocation from conn and answers with an empty
+// successful response for the same client handle.
+func serveCall(conn net.Conn) (proc string, err error) {
+	msg, err := readTestMessage(conn)
+	if err != nil {
+		return "", err
+	}
+	if proc, err = readString(msg); err != nil {
+		return "", err
+	}
+	handle, err := readLong(msg)
+	if err != nil {
+		return "", err
+	}
+	return proc, writeTestResponse(conn, handle)
+}
+
+// readRawTestMessage returns a message following its length, from the
+// version byte on.
+func readRawTestMessage(r io.Reader) ([]byte, error) {
+	size, err := readInt(r)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, size)
+	if _, err = io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func readTestMessage(r io.Reader) (*bytes.Buffer, error) {
+	data, err := readRawTestMessage(r)
+	if err != nil {
+		return nil, err
+	}
+	// skip the version
+	return bytes.NewBuffer(data[1:]), nil
+}
+
+func writeTestMessage(w io.Writer, msg bytes.Buffer) error {
+	var netmsg bytes.Buffer
+	writeInt(&netmsg, int32(msg.Len()+1))
+	writeProtoVersion(&netmsg)
+	netmsg.Write(msg.Bytes())
+	_, err := w.Write(netmsg.Bytes())
+	return err
+}
+
+func TestCustomDialer(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	var network, addr string
+	dialer := func(ctx context.Context, n, a string) (net.Conn, error) {
+		network, addr = n, a
+		return client, nil
+	}
+	go func() {
+		if err := serveLogin(server); err != nil {
+			return
+		}
+		serveCall(server)
+	}()
+
+	conn, err := NewConnectionWithDialer(context.Background(), dialer, "user", "", "volt:21212")
+	if err != nil {
+		t.Fatalf("Unexpected connection error: %v", err)
+	}
+	defer conn.Close()
+	if network != "tcp" || addr != "volt:21212" {
+		t.Errorf("Dialer invoked with %v %v", network, addr)
+	}
+	if conn.connData.buildString != "test" {
+		t.Errorf("Bad login response. Have %v", conn.connData.buildString)
+	}
+	if !conn.TestConnection() {
+		t.Errorf("Expected successful @Ping over custom transport")
+	}
+}
+
 func TestCallOnClosedConn(t *testing.T) {
-	conn := Conn{nil, nil}
+	conn := Conn{}
 	_, err := conn.Call("bad", 1, 2)
 	if err == nil {
 		t.Errorf("Expected error calling procedure on closed Conn")
 	}
 }
 
+func TestCallTimeoutAndPriority(t *testing.T) {
+	conn, server := pipeConn(t)
+	defer conn.Close()
+
+	received := make(chan []byte, 1)
+	go func() {
+		msg, err := readRawTestMessage(server)
+		if err != nil {
+			return
+		}
+		received <- msg
+	}()
+	_, err := conn.Call("Slow", 1, WithTimeout(20*time.Millisecond), WithPriority(3))
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("Expected timeout error. Have %v", err)
+	}
+
+	msg := <-received
+	var expected bytes.Buffer
+	writeByte(&expected, invocationVersion2)
+	writeByte(&expected, batchTimeoutOverride)
+	writeInt(&expected, 20)
+	writeByte(&expected, 3)
+	writeString(&expected, "Slow")
+	if !bytes.HasPrefix(msg, expected.Bytes()) {
+		t.Fatalf("Bad invocation header. Have % x expected % x", msg[:min(len(msg), expected.Len())], expected.Bytes())
+	}
+	handle, _ := readLong(bytes.NewBuffer(msg[expected.Len():]))
+
+	// a late response is discarded and the connection remains usable.
+	go func() {
+		writeTestResponse(server, handle)
+		msg, err := readRawTestMessage(server)
+		if err != nil {
+			return
+		}
+		received <- msg
+		writeTestResponse(server, 42)
+	}()
+	rsp, err := conn.Call("@Ping", WithHandle(42))
+	if err != nil {
+		t.Fatalf("Unexpected call error: %v", err)
+	}
+	if rsp.ClientHandle() != 42 {
+		t.Errorf("Bad client handle. Have %v expected 42", rsp.ClientHandle())
+	}
+
+	// a plain call uses the original format: no fields precede the name.
+	msg = <-received
+	expected.Reset()
+	writeByte(&expected, invocationVersion0)
+	writeString(&expected, "@Ping")
+	writeLong(&expected, 42)
+	if !bytes.HasPrefix(msg, expected.Bytes()) {
+		t.Errorf("Bad plain invocation. Have % x expected % x", msg[:min(len(msg), expected.Len())], expected.Bytes())
+	}
+}
+
+func TestCallOptionErrors(t *testing.T) {
+	conn, server := pipeConn(t)
+	defer conn.Close()
+	defer server.Close()
+	if _, err := conn.Call("Proc", WithPriority(9)); err == nil {
+		t.Errorf("Expected error for invalid priority")
+	}
+	if _, err := conn.Call("Proc", WithTimeout(-time.Second)); err == nil {
+		t.Errorf("Expected error for negative timeout")
+	}
+}
+
 func TestTableAccessors(t *testing.T) {
 	statusCode := 1
 	columnCount := 10
 	columnTypes := []int8{1, 2, 3}
 	columnNames := []string{"abc", "def", "ghi"}
 	rowCount := 5
-	rows := bytes.NewBufferString("rowbuf")
 	table := Table{
-		int8(statusCode),
-		int16(columnCount),
-		columnTypes,
-		columnNames,
-		int32(rowCount),
-		*rows}
+		statusCode:  int8(statusCode),
+		columnCount: int16(columnCount),
+		columnTypes: columnTypes,
+		columnNames: columnNames,
+		rowCount:    int32(rowCount),
+		data:        []byte("rowbuf")}
 
 	if table.StatusCode() != statusCode {
 		t.Errorf("Bad StatusCode()")
@@ -46,3 +244,58 @@ func TestTableAccessors(t *testing.T) {
 		t.Errorf("Bad RowCount()")
 	}
 }
+
+func TestReconnectReleasesLock(t *testing.T) {
+	var dials atomic.Int32
+	dialer := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if dials.Add(1) > 1 {
+			return nil, errors.New("refused")
+		}
+		client, server := net.Pipe()
+		go func() {
+			serveLogin(server)
+			server.Close()
+		}()
+		return client, nil
+	}
+	conn, err := DialWithOptions(WithAddresses("pipe:21212"), WithDialer(dialer),
+		WithReconnect(5, 100*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Unexpected connection error: %v", err)
+	}
+	for {
+		conn.mu.Lock()
+		lost := conn.netConn == nil
+		conn.mu.Unlock()
+		if lost {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	called := make(chan error, 1)
+	go func() {
+		_, err := conn.Call("@Ping")
+		called <- err
+	}()
+	for dials.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	start := time.Now()
+	conn.GoString()
+	conn.Close()
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("Close waited %v for the reconnect", elapsed)
+	}
+	select {
+	case err := <-called:
+		if err == nil {
+			t.Errorf("Expected call on closed Conn to fail")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Close did not stop the reconnect")
+	}
+	if dials.Load() > 3 {
+		t.Errorf("Reconnect continued after Close, %d dials", dials.Load())
+	}
+}
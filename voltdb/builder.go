@@ -0,0 +1,347 @@
+package voltdb
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// builder.go serializes tables on the client, to pass as procedure
+// parameters or to stand in for results in tests.
+
+// A TableBuilder accumulates columns and rows and builds a Table from
+// them. All columns must be added before the first row.
+type TableBuilder struct {
+	names    []string
+	types    []int8
+	rows     bytes.Buffer
+	rowCount int32
+	row      bytes.Buffer
+
+	precision TimestampPrecision
+}
+
+// NewTableBuilder returns a TableBuilder with no columns.
+func NewTableBuilder() *TableBuilder {
+	return new(TableBuilder)
+}
+
+// AddColumn appends a column named name of type ct.
+func (b *TableBuilder) AddColumn(name string, ct ColumnType) error {
+	if b.rowCount > 0 {
+		return fmt.Errorf("Can not add column %v after adding rows.", name)
+	}
+	if name == "" {
+		return fmt.Errorf("Column name must not be empty.")
+	}
+	for _, existing := range b.names {
+		if strings.EqualFold(existing, name) {
+			return fmt.Errorf("Duplicate column %v.", name)
+		}
+	}
+	switch int8(ct) {
+	case vt_TINYINT, vt_SHORT, vt_INT, vt_LONG, vt_FLOAT, vt_STRING,
+		vt_TIMESTAMP, vt_TABLE, vt_DECIMAL, vt_BOOLEAN, vt_VARBIN,
+		vt_GEOPOINT, vt_GEOGRAPHY:
+	default:
+		return fmt.Errorf("Unsupported type %v for column %v.", ct, name)
+	}
+	b.names = append(b.names, name)
+	b.types = append(b.types, int8(ct))
+	return nil
+}
+
+// SetTimestampPrecision sets how times more precise than a microsecond
+// are stored. By default they are truncated.
+func (b *TableBuilder) SetTimestampPrecision(p TimestampPrecision) {
+	b.precision = p
+}
+
+// AddRow appends a row holding values, one per column in column order.
+// Values are converted to their column's type; nil and nil pointers
+// are NULL. Floats in DECIMAL columns are rounded to 12 decimal places,
+// while more precise strings and *big.Rat values are errors. A row with
+// a value that can not be converted is not added.
+func (b *TableBuilder) AddRow(values ...interface{}) error {
+	if len(b.types) == 0 {
+		return fmt.Errorf("Can not add rows to a table without columns.")
+	}
+	if len(values) != len(b.types) {
+		return fmt.Errorf("Row has %d values, expected %d.", len(values), len(b.types))
+	}
+	b.row.Reset()
+	for idx, val := range values {
+		if err := encodeValue(&b.row, b.names[idx], b.types[idx], val, b.precision); err != nil {
+			return err
+		}
+	}
+	writeInt(&b.rows, int32(b.row.Len()))
+	b.rows.Write(b.row.Bytes())
+	b.rowCount++
+	return nil
+}
+
+// AddStruct appends a row holding the fields of the struct, or struct
+// pointer, v. Columns are matched to fields as they are by Table.Next;
+// every column must have a field.
+func (b *TableBuilder) AddStruct(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("Must supply a struct to add as a row.")
+	}
+	byName := make(map[string]rowField)
+	for _, f := range rowFields(rv.Type()) {
+		byName[strings.ToUpper(f.name)] = f
+	}
+	values := make([]interface{}, len(b.names))
+	for idx, name := range b.names {
+		f, ok := byName[strings.ToUpper(name)]
+		if !ok {
+			return fmt.Errorf("No field for column %v in %v.", name, rv.Type())
+		}
+		// a field of a nil embedded pointer is NULL.
+		if fv, err := rv.FieldByIndexErr(f.index); err == nil {
+			values[idx] = fv.Interface()
+		}
+	}
+	return b.AddRow(values...)
+}
+
+// Build returns a Table holding the rows added so far, positioned at
+// its first row. The builder may go on to add more rows.
+func (b *TableBuilder) Build() *Table {
+	t := &Table{
+		// VoltDB's status code for tables that do not set one.
+		statusCode:  nullTinyInt,
+		columnCount: int16(len(b.types)),
+		columnTypes: append([]int8(nil), b.types...),
+		columnNames: append([]string(nil), b.names...),
+		rowCount:    b.rowCount,
+		data:        append([]byte(nil), b.rows.Bytes()...),
+		index:       new(rowIndex),
+	}
+	t.Reset()
+	return t
+}
+
+// encodeValue writes val as a value of column type vt.
+func encodeValue(buf *bytes.Buffer, column string, vt int8, val interface{}, prec TimestampPrecision) error {
+	switch v := val.(type) {
+	case VoltMarshaler:
+		ct, raw, err := v.MarshalVolt()
+		if err != nil {
+			return err
+		}
+		if raw == nil {
+			return encodeNull(buf, column, vt)
+		}
+		if ct != ColumnType(vt) {
+			return fmt.Errorf("Column %v has type %v but %T marshals %v.", column, ColumnType(vt), val, ct)
+		}
+		if vt == vt_STRING || vt == vt_VARBIN || vt == vt_GEOGRAPHY {
+			return writeByteString(buf, raw)
+		}
+		_, err = buf.Write(raw)
+		return err
+	case driver.Valuer:
+		dv, err := v.Value()
+		if err != nil {
+			return err
+		}
+		if _, ok := dv.(driver.Valuer); ok {
+			return fmt.Errorf("Value of %T is itself a driver.Valuer.", val)
+		}
+		val = dv
+	}
+
+	rv := reflect.ValueOf(val)
+	if rv.Kind() == reflect.Ptr && !rv.IsNil() && rv.Type() != ratPtrType {
+		rv = rv.Elem()
+		val = rv.Interface()
+	}
+	if !rv.IsValid() || (rv.Kind() == reflect.Ptr && rv.IsNil()) {
+		return encodeNull(buf, column, vt)
+	}
+
+	switch vt {
+	case vt_TINYINT, vt_SHORT, vt_INT, vt_LONG:
+		n, ok := integerValue(rv)
+		if !ok {
+			break
+		}
+		// the smallest value of each width is NULL.
+		if limit := integerLimit(vt); n > limit || n < -limit {
+			return fmt.Errorf("Value %v does not fit in column %v (%v).", val, column, ColumnType(vt))
+		}
+		switch vt {
+		case vt_TINYINT:
+			return writeByte(buf, int8(n))
+		case vt_SHORT:
+			return writeShort(buf, int16(n))
+		case vt_INT:
+			return writeInt(buf, int32(n))
+		}
+		return writeLong(buf, n)
+	case vt_BOOLEAN:
+		if rv.Kind() == reflect.Bool {
+			return writeBoolean(buf, rv.Bool())
+		}
+	case vt_FLOAT:
+		switch rv.Kind() {
+		case reflect.Float32, reflect.Float64:
+			// values at or below nullFloat read back as NULL.
+			if rv.Float() <= nullFloat {
+				return fmt.Errorf("Value %v does not fit in column %v (%v).", val, column, ColumnType(vt))
+			}
+			return writeFloat(buf, rv.Float())
+		}
+		if n, ok := integerValue(rv); ok {
+			return writeFloat(buf, float64(n))
+		}
+	case vt_STRING, vt_VARBIN:
+		switch {
+		case rv.Kind() == reflect.String:
+			return writeString(buf, rv.String())
+		case rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8:
+			if rv.IsNil() {
+				return encodeNull(buf, column, vt)
+			}
+			return writeByteString(buf, rv.Bytes())
+		}
+	case vt_TIMESTAMP:
+		switch v := val.(type) {
+		case time.Time:
+			if err := writeTimestamp(buf, v, prec); err != nil {
+				return fmt.Errorf("Column %v: %v", column, err)
+			}
+			return nil
+		case int64:
+			// microseconds since the epoch.
+			if v < minTimestamp || v > maxTimestamp {
+				return fmt.Errorf("Value %v does not fit in column %v (%v).", v, column, ColumnType(vt))
+			}
+			return writeLong(buf, v)
+		}
+	case vt_DECIMAL:
+		var d *big.Rat
+		switch v := val.(type) {
+		case *big.Rat:
+			d = v
+		case big.Rat:
+			d = &v
+		case string:
+			var ok bool
+			if d, ok = new(big.Rat).SetString(v); !ok {
+				return fmt.Errorf("Value %q of column %v is not a decimal.", v, column)
+			}
+		default:
+			if n, ok := integerValue(rv); ok {
+				d = new(big.Rat).SetInt64(n)
+			} else if k := rv.Kind(); k == reflect.Float32 || k == reflect.Float64 {
+				if d = floatDecimal(rv.Float(), rv.Type().Bits()); d == nil {
+					return fmt.Errorf("Value %v of column %v is not a decimal.", val, column)
+				}
+			}
+		}
+		if d != nil {
+			if err := writeDecimal(buf, d); err != nil {
+				return fmt.Errorf("Column %v: %v", column, err)
+			}
+			return nil
+		}
+	case vt_GEOPOINT:
+		p, ok := val.(GeographyPoint)
+		if s, isString := val.(string); isString {
+			var err error
+			if p, err = ParseGeographyPoint(s); err != nil {
+				return err
+			}
+		} else if !ok {
+			break
+		}
+		if err := p.validate(); err != nil {
+			return err
+		}
+		return writeGeographyPoint(buf, p)
+	case vt_GEOGRAPHY:
+		g, ok := val.(Geography)
+		if s, isString := val.(string); isString {
+			var err error
+			if g, err = ParseGeography(s); err != nil {
+				return err
+			}
+		} else if !ok {
+			break
+		}
+		var raw bytes.Buffer
+		if err := writeGeography(&raw, g); err != nil {
+			return err
+		}
+		return writeByteString(buf, raw.Bytes())
+	case vt_TABLE:
+		if t, ok := val.(Table); ok {
+			return serializeTable(buf, &t)
+		}
+		if t, ok := val.(*Table); ok {
+			return serializeTable(buf, t)
+		}
+	}
+	return fmt.Errorf("Can not convert %T to column %v (%v).", val, column, ColumnType(vt))
+}
+
+// integerValue returns the value of an integer kind, reporting false
+// for other kinds and unsigned values beyond int64.
+func integerValue(rv reflect.Value) (int64, bool) {
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n := rv.Uint()
+		return int64(n), n <= 1<<63-1
+	}
+	return 0, false
+}
+
+// integerLimit returns the largest value of integer column type vt.
+func integerLimit(vt int8) int64 {
+	switch vt {
+	case vt_TINYINT:
+		return math.MaxInt8
+	case vt_SHORT:
+		return math.MaxInt16
+	case vt_INT:
+		return math.MaxInt32
+	}
+	return math.MaxInt64
+}
+
+// encodeNull writes the NULL value of column type vt.
+func encodeNull(buf *bytes.Buffer, column string, vt int8) error {
+	switch vt {
+	case vt_TINYINT, vt_BOOLEAN:
+		return writeByte(buf, nullTinyInt)
+	case vt_SHORT:
+		return writeShort(buf, nullSmallInt)
+	case vt_INT:
+		return writeInt(buf, nullInteger)
+	case vt_LONG, vt_TIMESTAMP:
+		return writeLong(buf, nullBigInt)
+	case vt_FLOAT:
+		return writeFloat(buf, nullFloat)
+	case vt_STRING, vt_VARBIN, vt_GEOGRAPHY:
+		return writeInt(buf, -1)
+	case vt_GEOPOINT:
+		return writeGeographyPoint(buf, GeographyPoint{nullCoord, nullCoord})
+	case vt_DECIMAL:
+		return writeDecimal(buf, nil)
+	}
+	return fmt.Errorf("Column %v can not be NULL.", column)
+}
@@ -0,0 +1,173 @@
+package voltdb
+
+import (
+	"bytes"
+	"math"
+	"math/big"
+	"testing"
+	"time"
+)
+
+type builderRow struct {
+	Tiny   int8
+	Small  int16
+	Int    int32
+	Big    int64
+	Float  float64
+	Name   string
+	When   *time.Time
+	Amount *big.Rat
+	Blob   []byte
+}
+
+func builderTestTable(t *testing.T) *TableBuilder {
+	return testBuilder(t,
+		[]string{"TINY", "SMALL", "INT", "BIG", "FLOAT", "NAME", "WHEN", "AMOUNT", "BLOB"},
+		[]ColumnType{TypeTinyInt, TypeSmallInt, TypeInteger, TypeBigInt, TypeFloat,
+			TypeString, TypeTimestamp, TypeDecimal, TypeVarbinary})
+}
+
+func TestTableBuilderRoundTrip(t *testing.T) {
+	b := builderTestTable(t)
+	when := time.UnixMicro(1400000000123456).UTC()
+	if err := b.AddRow(1, uint16(2), int64(3), 4, 5.5, "five", when, "-12.25", []byte{6}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := b.AddStruct(&builderRow{Tiny: -1, Name: "struct", Amount: big.NewRat(1, 4)}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := b.AddRow(nil, nil, nil, nil, nil, nil, nil, nil, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	table := b.Build()
+
+	// the serialized table reads back byte for byte.
+	var buf bytes.Buffer
+	if err := serializeTable(&buf, table); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	serialized := append([]byte(nil), buf.Bytes()...)
+	copied, err := deserializeTable(&buf)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	buf.Reset()
+	serializeTable(&buf, &copied)
+	if !bytes.Equal(serialized, buf.Bytes()) {
+		t.Errorf("Serialized tables differ")
+	}
+
+	var row builderRow
+	if err := copied.Next(&row); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if row.Tiny != 1 || row.Small != 2 || row.Int != 3 || row.Big != 4 || row.Float != 5.5 ||
+		row.Name != "five" || row.When == nil || !row.When.Equal(when) || row.Amount.FloatString(2) != "-12.25" ||
+		!bytes.Equal(row.Blob, []byte{6}) {
+		t.Errorf("Bad first row %#v", row)
+	}
+	if err := copied.Next(&row); err != nil || row.Tiny != -1 || row.Name != "struct" ||
+		row.Amount.FloatString(2) != "0.25" || row.When != nil {
+		t.Errorf("Bad struct row %#v: %v", row, err)
+	}
+	values, err := copied.NextValues()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for idx, val := range values {
+		if val != nil {
+			t.Errorf("Expected NULL in column %d, have %v", idx, val)
+		}
+	}
+}
+
+func TestTableBuilderTimestampPrecision(t *testing.T) {
+	b := builderTestTable(t)
+	when := time.Date(2024, 1, 2, 3, 4, 5, 123456789, time.UTC)
+	if err := b.AddRow(1, 2, 3, 4, 5.0, "x", when, "1", nil); err != nil {
+		t.Fatalf("Unexpected error truncating a time: %v", err)
+	}
+	b.SetTimestampPrecision(TimestampError)
+	if err := b.AddRow(1, 2, 3, 4, 5.0, "x", when, "1", nil); err == nil {
+		t.Errorf("Expected error adding a sub-microsecond time")
+	}
+	var row builderRow
+	table := b.Build()
+	if err := table.Next(&row); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !row.When.Equal(when.Truncate(time.Microsecond)) {
+		t.Errorf("Bad truncated time. Have %v expected %v", row.When, when)
+	}
+	if table.RowCount() != 1 {
+		t.Errorf("Expected the failed row not to be added")
+	}
+}
+
+func TestTableBuilderErrors(t *testing.T) {
+	b := builderTestTable(t)
+	when := time.Now().Truncate(time.Microsecond)
+	bad := [][]interface{}{
+		{1, 2, 3, 4, 5.0, "x", when, "1"},
+		{128, 2, 3, 4, 5.0, "x", when, "1", nil},
+		{-128, 2, 3, 4, 5.0, "x", when, "1", nil},
+		{1, 2, 3, uint64(1 << 63), 5.0, "x", when, "1", nil},
+		{1, 2, 3, 4, "five", "x", when, "1", nil},
+		{1, 2, 3, 4, -math.MaxFloat64, "x", when, "1", nil},
+		{1, 2, 3, 4, 5.0, "x", when, "0.0000000000001", nil},
+		{1, 2, 3, 4, 5.0, "x", when, "one", nil},
+		{1, 2, 3, 4, 5.0, "x", time.Time{}, "1", nil},
+	}
+	for _, row := range bad {
+		if err := b.AddRow(row...); err == nil {
+			t.Errorf("Expected error adding row %v", row)
+		}
+	}
+	if err := b.AddStruct(struct{ Tiny int8 }{}); err == nil {
+		t.Errorf("Expected error adding struct without all columns")
+	}
+	if table := b.Build(); table.RowCount() != 0 {
+		t.Errorf("Expected failed rows not to be added")
+	}
+
+	if err := b.AddColumn("tiny", TypeTinyInt); err == nil {
+		t.Errorf("Expected error adding duplicate column")
+	}
+	if err := b.AddColumn("other", ColumnType(vt_ARRAY)); err == nil {
+		t.Errorf("Expected error adding unsupported column type")
+	}
+	b.AddRow(1, 2, 3, 4, 5.0, "x", when, "1", nil)
+	if err := b.AddColumn("late", TypeInteger); err == nil {
+		t.Errorf("Expected error adding column after rows")
+	}
+}
+
+func TestTableBuilderFloatDecimals(t *testing.T) {
+	b := NewTableBuilder()
+	b.AddColumn("AMOUNT", TypeDecimal)
+	values := []struct {
+		in       interface{}
+		expected *big.Rat
+	}{
+		{0.1, big.NewRat(1, 10)},
+		{float32(0.1), big.NewRat(1, 10)},
+		{-2.5, big.NewRat(-5, 2)},
+		{1.0000000000005, big.NewRat(1000000000001, 1000000000000)},
+		{-1.0000000000005, big.NewRat(-1000000000001, 1000000000000)},
+	}
+	for _, v := range values {
+		if err := b.AddRow(v.in); err != nil {
+			t.Fatalf("Unexpected error adding %v: %v", v.in, err)
+		}
+	}
+	table := b.Build()
+	for _, v := range values {
+		var row struct{ Amount *big.Rat }
+		if err := table.Next(&row); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if row.Amount.Cmp(v.expected) != 0 {
+			t.Errorf("Bad decimal for %v. Have %v", v.in, row.Amount.RatString())
+		}
+	}
+}
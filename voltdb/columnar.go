@@ -0,0 +1,178 @@
+package voltdb
+
+import (
+	"bytes"
+	"sync"
+	"time"
+)
+
+// columnar.go decodes whole tables into a slice per column, for
+// analysis that works a column at a time.
+
+// ColumnData holds every value of one column. The values are in the
+// slice matching the column's type: Int64s for the integer types,
+// Float64s for FLOAT, Strings for VARCHAR, Times for TIMESTAMP, Bools
+// for BOOLEAN and Values, holding what NextValues would return, for any
+// other type. A NULL value is the zero value of its slice and has its
+// bit set in Nulls.
+type ColumnData struct {
+	Name string
+	Type ColumnType
+
+	Int64s   []int64
+	Float64s []float64
+	Strings  []string
+	Times    []time.Time
+	Bools    []bool
+	Values   []interface{}
+
+	// Nulls has bit i%64 of word i/64 set when row i is NULL.
+	Nulls []uint64
+}
+
+// Len returns the number of values in the column.
+func (c *ColumnData) Len() int {
+	switch c.Type {
+	case TypeTinyInt, TypeSmallInt, TypeInteger, TypeBigInt:
+		return len(c.Int64s)
+	case TypeFloat:
+		return len(c.Float64s)
+	case TypeString:
+		return len(c.Strings)
+	case TypeTimestamp:
+		return len(c.Times)
+	case TypeBoolean:
+		return len(c.Bools)
+	}
+	return len(c.Values)
+}
+
+// IsNull reports whether row i of the column is NULL.
+func (c *ColumnData) IsNull(i int) bool {
+	return c.Nulls[i/64]&(1<<(i%64)) != 0
+}
+
+// Columnar decodes all rows of the table, whatever its read position,
+// into one ColumnData per column.
+func (table *Table) Columnar() ([]ColumnData, error) {
+	return table.ColumnarParallel(1)
+}
+
+// ColumnarParallel is Columnar with up to workers goroutines decoding
+// different columns at once.
+func (table *Table) ColumnarParallel(workers int) ([]ColumnData, error) {
+	offsets, err := table.rowOffsets()
+	if err != nil {
+		return nil, err
+	}
+	columns := table.newColumnData(len(offsets))
+	if workers <= 1 || len(columns) <= 1 {
+		var r bytes.Buffer
+		for row, offset := range offsets {
+			r = *bytes.NewBuffer(table.data[offset+4:])
+			for col := range columns {
+				if err := columns[col].decodeCell(&r, row, table.location); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return columns, nil
+	}
+
+	// find every cell, then decode the columns independently.
+	cells := make([]int, len(offsets)*len(columns))
+	for row, offset := range offsets {
+		r := bytes.NewBuffer(table.data[offset+4:])
+		for col, vt := range table.columnTypes {
+			cells[row*len(columns)+col] = len(table.data) - r.Len()
+			if err := skipValue(r, vt); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	work := make(chan int)
+	errs := make([]error, len(columns))
+	var wg sync.WaitGroup
+	for w := 0; w < min(workers, len(columns)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var r bytes.Buffer
+			for col := range work {
+				for row := range offsets {
+					r = *bytes.NewBuffer(table.data[cells[row*len(columns)+col]:])
+					if errs[col] = columns[col].decodeCell(&r, row, table.location); errs[col] != nil {
+						break
+					}
+				}
+			}
+		}()
+	}
+	for col := range columns {
+		work <- col
+	}
+	close(work)
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return columns, nil
+}
+
+// newColumnData allocates the ColumnData of rows rows for each column.
+func (table *Table) newColumnData(rows int) []ColumnData {
+	columns := make([]ColumnData, len(table.columnTypes))
+	for idx, vt := range table.columnTypes {
+		c := &columns[idx]
+		c.Name, c.Type = table.columnNames[idx], ColumnType(vt)
+		c.Nulls = make([]uint64, (rows+63)/64)
+		switch c.Type {
+		case TypeTinyInt, TypeSmallInt, TypeInteger, TypeBigInt:
+			c.Int64s = make([]int64, rows)
+		case TypeFloat:
+			c.Float64s = make([]float64, rows)
+		case TypeString:
+			c.Strings = make([]string, rows)
+		case TypeTimestamp:
+			c.Times = make([]time.Time, rows)
+		case TypeBoolean:
+			c.Bools = make([]bool, rows)
+		default:
+			c.Values = make([]interface{}, rows)
+		}
+	}
+	return columns
+}
+
+// decodeCell reads the value at the front of r into row i of c.
+func (c *ColumnData) decodeCell(r *bytes.Buffer, i int, loc *time.Location) error {
+	vt := int8(c.Type)
+	if isNull(r.Bytes(), vt) {
+		c.Nulls[i/64] |= 1 << (i % 64)
+		return skipValue(r, vt)
+	}
+
+	var err error
+	switch c.Type {
+	case TypeTinyInt, TypeSmallInt, TypeInteger, TypeBigInt:
+		c.Int64s[i], _, err = readInteger(r, vt)
+	case TypeFloat:
+		c.Float64s[i], err = readFloat(r)
+	case TypeString:
+		c.Strings[i], err = readString(r)
+	case TypeTimestamp:
+		var t time.Time
+		t, err = readTimestamp(r)
+		c.Times[i] = inLocation(t, loc)
+	case TypeBoolean:
+		var v int64
+		v, _, err = readInteger(r, vt)
+		c.Bools[i] = v != 0
+	default:
+		c.Values[i], err = readValue(r, vt, loc)
+	}
+	return err
+}
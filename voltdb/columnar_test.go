@@ -0,0 +1,64 @@
+package voltdb
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func columnarTestTable(t *testing.T) *Table {
+	when := time.Unix(1400000000, 0)
+	return testBuilder(t, []string{"ID", "SCORE", "NAME", "AT", "FLAG", "BLOB"},
+		[]ColumnType{TypeInteger, TypeFloat, TypeString, TypeTimestamp, TypeBoolean, TypeVarbinary},
+		[]interface{}{1, 1.5, "one", when, true, []byte{1}},
+		[]interface{}{nil, nil, "two", when, false, nil},
+		[]interface{}{3, 3.5, "three", when, true, []byte{3}}).Build()
+}
+
+func TestColumnar(t *testing.T) {
+	table := columnarTestTable(t)
+	table.Next(&struct{}{})
+	columns, err := table.Columnar()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(columns) != 6 || columns[0].Name != "ID" || columns[0].Type != TypeInteger {
+		t.Fatalf("Bad columns %v", columns)
+	}
+	if !reflect.DeepEqual(columns[0].Int64s, []int64{1, 0, 3}) || !columns[0].IsNull(1) || columns[0].IsNull(0) {
+		t.Errorf("Bad ID column %v %v", columns[0].Int64s, columns[0].Nulls)
+	}
+	if !reflect.DeepEqual(columns[1].Float64s, []float64{1.5, 0, 3.5}) || !columns[1].IsNull(1) {
+		t.Errorf("Bad SCORE column %v", columns[1].Float64s)
+	}
+	if !reflect.DeepEqual(columns[2].Strings, []string{"one", "two", "three"}) || columns[2].Nulls[0] != 0 {
+		t.Errorf("Bad NAME column %v", columns[2].Strings)
+	}
+	if columns[3].Len() != 3 || columns[3].Times[2].Unix() != 1400000000 {
+		t.Errorf("Bad AT column %v", columns[3].Times)
+	}
+	if !reflect.DeepEqual(columns[4].Bools, []bool{true, false, true}) {
+		t.Errorf("Bad FLAG column %v", columns[4].Bools)
+	}
+	if columns[5].Values[1] != nil || !columns[5].IsNull(1) || columns[5].Len() != 3 {
+		t.Errorf("Bad BLOB column %v", columns[5].Values)
+	}
+
+	parallel, err := table.ColumnarParallel(4)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(columns, parallel) {
+		t.Errorf("Parallel columns differ")
+	}
+}
+
+func BenchmarkColumnar(b *testing.B) {
+	table := benchmarkTable(1000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := table.Columnar(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
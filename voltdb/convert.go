@@ -0,0 +1,505 @@
+package voltdb
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// convert.go decodes column values into Go values. Each column is
+// converted to its destination field by a decodeFunc chosen once, when
+// a decodePlan is built, from the column type and the field type.
+//
+// SQL NULL is stored as the zero value of the field, or as nil in a
+// pointer or interface field.
+
+// VoltDB represents NULL numbers with the minimum value of the type.
+const (
+	nullTinyInt  = math.MinInt8
+	nullSmallInt = math.MinInt16
+	nullInteger  = math.MinInt32
+	nullBigInt   = math.MinInt64
+	nullFloat    = -1.7e308
+)
+
+var (
+	timeType   = reflect.TypeOf(time.Time{})
+	ratPtrType = reflect.TypeOf((*big.Rat)(nil))
+	tableType  = reflect.TypeOf(Table{})
+
+	geographyPointType = reflect.TypeOf(GeographyPoint{})
+	geographyType      = reflect.TypeOf(Geography{})
+)
+
+type decodeFunc func(r *bytes.Buffer, field reflect.Value) error
+
+// readInteger reads a value of one of the integer volt types, or a
+// BOOLEAN as 0 or 1, and reports whether it is NULL.
+func readInteger(r *bytes.Buffer, vt int8) (val int64, null bool, err error) {
+	switch vt {
+	case vt_TINYINT, vt_BOOLEAN:
+		v, err := readByte(r)
+		return int64(v), v == nullTinyInt, err
+	case vt_SHORT:
+		v, err := readShort(r)
+		return int64(v), v == nullSmallInt, err
+	case vt_INT:
+		v, err := readInt(r)
+		return int64(v), v == nullInteger, err
+	}
+	v, err := readLong(r)
+	return v, v == nullBigInt, err
+}
+
+// readValue reads a value of volt type vt as its natural Go type, or
+// nil for NULL. Timestamps are in loc.
+func readValue(r *bytes.Buffer, vt int8, loc *time.Location) (interface{}, error) {
+	switch vt {
+	case vt_TINYINT, vt_SHORT, vt_INT, vt_LONG:
+		val, null, err := readInteger(r, vt)
+		if err != nil || null {
+			return nil, err
+		}
+		switch vt {
+		case vt_TINYINT:
+			return int8(val), nil
+		case vt_SHORT:
+			return int16(val), nil
+		case vt_INT:
+			return int32(val), nil
+		}
+		return val, nil
+	case vt_BOOLEAN:
+		val, null, err := readInteger(r, vt)
+		if err != nil || null {
+			return nil, err
+		}
+		return val != 0, nil
+	case vt_FLOAT:
+		val, err := readFloat(r)
+		if err != nil || val <= nullFloat {
+			return nil, err
+		}
+		return val, nil
+	case vt_STRING:
+		if isNull(r.Bytes(), vt) {
+			return nil, skipValue(r, vt)
+		}
+		return readString(r)
+	case vt_TIMESTAMP:
+		if isNull(r.Bytes(), vt) {
+			return nil, skipValue(r, vt)
+		}
+		val, err := readTimestamp(r)
+		return inLocation(val, loc), err
+	case vt_VARBIN:
+		val, err := readByteString(r)
+		if err != nil || val == nil {
+			return nil, err
+		}
+		return val, nil
+	case vt_DECIMAL:
+		val, err := readDecimal(r)
+		if err != nil || val == nil {
+			return nil, err
+		}
+		return val, nil
+	case vt_TABLE:
+		val, err := deserializeTable(r)
+		if err != nil {
+			return nil, err
+		}
+		val.location = loc
+		return &val, nil
+	case vt_GEOPOINT:
+		val, null, err := readGeographyPoint(r)
+		if err != nil || null {
+			return nil, err
+		}
+		return val, nil
+	case vt_GEOGRAPHY:
+		raw, err := readRaw(r, vt)
+		if err != nil || raw == nil {
+			return nil, err
+		}
+		return readGeography(raw)
+	}
+	return nil, fmt.Errorf("Can not deserialize column type %d.", vt)
+}
+
+// isNull reports whether data begins with the NULL value of type vt.
+func isNull(data []byte, vt int8) bool {
+	switch vt {
+	case vt_TINYINT, vt_BOOLEAN:
+		return len(data) >= 1 && int8(data[0]) == nullTinyInt
+	case vt_SHORT:
+		return len(data) >= 2 && int16(order.Uint16(data)) == nullSmallInt
+	case vt_INT:
+		return len(data) >= 4 && int32(order.Uint32(data)) == nullInteger
+	case vt_LONG, vt_TIMESTAMP:
+		return len(data) >= 8 && int64(order.Uint64(data)) == nullBigInt
+	case vt_FLOAT:
+		return len(data) >= 8 && math.Float64frombits(order.Uint64(data)) <= nullFloat
+	case vt_STRING, vt_VARBIN, vt_GEOGRAPHY:
+		return len(data) >= 4 && int32(order.Uint32(data)) == -1
+	case vt_GEOPOINT:
+		return len(data) >= 16 && math.Float64frombits(order.Uint64(data)) == nullCoord &&
+			math.Float64frombits(order.Uint64(data[8:])) == nullCoord
+	case vt_DECIMAL:
+		return len(data) >= 16 && isNullDecimal(data)
+	}
+	return false
+}
+
+// conversionError describes a column that can not be stored in a field.
+func conversionError(column string, vt int8, field reflect.StructField) error {
+	return fmt.Errorf("Can not convert column %v (%v) to field %v (%v).",
+		column, ColumnType(vt), field.Name, field.Type)
+}
+
+// valueError describes a column value that does not fit in a field.
+func valueError(column string, val interface{}, field reflect.StructField) error {
+	return fmt.Errorf("Column %v value %v does not fit in field %v (%v).",
+		column, val, field.Name, field.Type)
+}
+
+// fieldDecoder returns the decodeFunc that stores column values of
+// volt type vt in field, or an error if no conversion exists. loc is
+// the location of decoded timestamps.
+func fieldDecoder(column string, vt int8, field reflect.StructField, loc *time.Location) (decodeFunc, error) {
+	t := field.Type
+
+	if decode := unmarshalerDecoder(vt, t, loc); decode != nil {
+		return decode, nil
+	}
+
+	if t.Kind() == reflect.Ptr && t != ratPtrType {
+		elemField := field
+		elemField.Type = t.Elem()
+		decode, err := fieldDecoder(column, vt, elemField, loc)
+		if err != nil {
+			return nil, err
+		}
+		return func(r *bytes.Buffer, f reflect.Value) error {
+			if isNull(r.Bytes(), vt) {
+				f.Set(reflect.Zero(t))
+				return skipValue(r, vt)
+			}
+			if f.IsNil() {
+				f.Set(reflect.New(t.Elem()))
+			}
+			return decode(r, f.Elem())
+		}, nil
+	}
+
+	if t.Kind() == reflect.Interface && t.NumMethod() == 0 {
+		return func(r *bytes.Buffer, f reflect.Value) error {
+			val, err := readValue(r, vt, loc)
+			if err != nil {
+				return err
+			}
+			if val == nil {
+				f.Set(reflect.Zero(t))
+			} else {
+				f.Set(reflect.ValueOf(val))
+			}
+			return nil
+		}, nil
+	}
+
+	switch vt {
+	case vt_TINYINT, vt_SHORT, vt_INT, vt_LONG, vt_BOOLEAN:
+		return integerDecoder(column, vt, field)
+	case vt_FLOAT:
+		return floatDecoder(column, vt, field)
+	case vt_STRING:
+		return stringDecoder(column, vt, field)
+	case vt_VARBIN:
+		return varbinaryDecoder(column, vt, field)
+	case vt_TIMESTAMP:
+		return timestampDecoder(column, vt, field, loc)
+	case vt_DECIMAL:
+		return decimalDecoder(column, vt, field)
+	case vt_TABLE:
+		return tableDecoder(column, vt, field, loc)
+	case vt_GEOPOINT, vt_GEOGRAPHY:
+		return geographyDecoder(column, vt, field)
+	}
+	return nil, fmt.Errorf("Unknown type %d in column %v.", vt, column)
+}
+
+func integerDecoder(column string, vt int8, field reflect.StructField) (decodeFunc, error) {
+	var set func(f reflect.Value, val int64) error
+	switch field.Type.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		set = func(f reflect.Value, val int64) error {
+			if f.OverflowInt(val) {
+				return valueError(column, val, field)
+			}
+			f.SetInt(val)
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		set = func(f reflect.Value, val int64) error {
+			if val < 0 || f.OverflowUint(uint64(val)) {
+				return valueError(column, val, field)
+			}
+			f.SetUint(uint64(val))
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		bits := field.Type.Bits()
+		set = func(f reflect.Value, val int64) error {
+			fval := float64(val)
+			if bits == 32 {
+				fval = float64(float32(fval))
+			}
+			// reject integers that the float can not represent exactly.
+			if fval >= 1<<63 || int64(fval) != val {
+				return valueError(column, val, field)
+			}
+			f.SetFloat(fval)
+			return nil
+		}
+	case reflect.Bool:
+		set = func(f reflect.Value, val int64) error {
+			f.SetBool(val != 0)
+			return nil
+		}
+	case reflect.String:
+		set = func(f reflect.Value, val int64) error {
+			if vt == vt_BOOLEAN {
+				f.SetString(strconv.FormatBool(val != 0))
+			} else {
+				f.SetString(strconv.FormatInt(val, 10))
+			}
+			return nil
+		}
+	default:
+		return nil, conversionError(column, vt, field)
+	}
+	return func(r *bytes.Buffer, f reflect.Value) error {
+		val, null, err := readInteger(r, vt)
+		if err != nil {
+			return err
+		}
+		if null {
+			f.Set(reflect.Zero(field.Type))
+			return nil
+		}
+		return set(f, val)
+	}, nil
+}
+
+func floatDecoder(column string, vt int8, field reflect.StructField) (decodeFunc, error) {
+	var set func(f reflect.Value, val float64) error
+	switch field.Type.Kind() {
+	case reflect.Float32, reflect.Float64:
+		set = func(f reflect.Value, val float64) error {
+			if f.OverflowFloat(val) {
+				return valueError(column, val, field)
+			}
+			f.SetFloat(val)
+			return nil
+		}
+	case reflect.String:
+		set = func(f reflect.Value, val float64) error {
+			f.SetString(strconv.FormatFloat(val, 'g', -1, 64))
+			return nil
+		}
+	default:
+		return nil, conversionError(column, vt, field)
+	}
+	return func(r *bytes.Buffer, f reflect.Value) error {
+		val, err := readFloat(r)
+		if err != nil {
+			return err
+		}
+		if val <= nullFloat {
+			f.Set(reflect.Zero(field.Type))
+			return nil
+		}
+		return set(f, val)
+	}, nil
+}
+
+func stringDecoder(column string, vt int8, field reflect.StructField) (decodeFunc, error) {
+	switch {
+	case field.Type.Kind() == reflect.String:
+		return func(r *bytes.Buffer, f reflect.Value) error {
+			val, err := readString(r)
+			f.SetString(val)
+			return err
+		}, nil
+	case field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.Uint8:
+		return func(r *bytes.Buffer, f reflect.Value) error {
+			val, err := readByteString(r)
+			f.SetBytes(val)
+			return err
+		}, nil
+	}
+	return nil, conversionError(column, vt, field)
+}
+
+func varbinaryDecoder(column string, vt int8, field reflect.StructField) (decodeFunc, error) {
+	switch {
+	case field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.Uint8:
+		return func(r *bytes.Buffer, f reflect.Value) error {
+			val, err := readByteString(r)
+			f.SetBytes(val)
+			return err
+		}, nil
+	case field.Type.Kind() == reflect.String:
+		return func(r *bytes.Buffer, f reflect.Value) error {
+			val, err := readByteString(r)
+			f.SetString(string(val))
+			return err
+		}, nil
+	}
+	return nil, conversionError(column, vt, field)
+}
+
+// inLocation returns t in loc, leaving the zero time of NULL alone.
+func inLocation(t time.Time, loc *time.Location) time.Time {
+	if t.IsZero() || loc == nil {
+		return t
+	}
+	return t.In(loc)
+}
+
+func timestampDecoder(column string, vt int8, field reflect.StructField, loc *time.Location) (decodeFunc, error) {
+	switch {
+	case field.Type == timeType:
+		return func(r *bytes.Buffer, f reflect.Value) error {
+			val, err := readTimestamp(r)
+			f.Set(reflect.ValueOf(inLocation(val, loc)))
+			return err
+		}, nil
+	case field.Type.Kind() == reflect.Int64:
+		// microseconds since the epoch. NULL is an error, as any value
+		// would be a real time; a *int64 field receives nil.
+		return func(r *bytes.Buffer, f reflect.Value) error {
+			val, err := readLong(r)
+			if err == nil && val == nullBigInt {
+				return fmt.Errorf("Column %v is a NULL TIMESTAMP, which field %v (%v) can not hold; use a pointer.",
+					column, field.Name, field.Type)
+			}
+			f.SetInt(val)
+			return err
+		}, nil
+	case field.Type.Kind() == reflect.String:
+		return func(r *bytes.Buffer, f reflect.Value) error {
+			if isNull(r.Bytes(), vt) {
+				f.SetString("")
+				return skipValue(r, vt)
+			}
+			val, err := readTimestamp(r)
+			f.SetString(inLocation(val, loc).Format(time.RFC3339Nano))
+			return err
+		}, nil
+	}
+	return nil, conversionError(column, vt, field)
+}
+
+func decimalDecoder(column string, vt int8, field reflect.StructField) (decodeFunc, error) {
+	var set func(f reflect.Value, val *big.Rat) error
+	switch field.Type.Kind() {
+	case reflect.Ptr:
+		// only *big.Rat reaches here; other pointers are dereferenced.
+		set = func(f reflect.Value, val *big.Rat) error {
+			f.Set(reflect.ValueOf(val))
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		set = func(f reflect.Value, val *big.Rat) error {
+			fval, _ := val.Float64()
+			if f.OverflowFloat(fval) {
+				return valueError(column, val.FloatString(decimalScale), field)
+			}
+			f.SetFloat(fval)
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		set = func(f reflect.Value, val *big.Rat) error {
+			if !val.IsInt() || !val.Num().IsInt64() || f.OverflowInt(val.Num().Int64()) {
+				return valueError(column, val.FloatString(decimalScale), field)
+			}
+			f.SetInt(val.Num().Int64())
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		set = func(f reflect.Value, val *big.Rat) error {
+			if !val.IsInt() || !val.Num().IsUint64() || f.OverflowUint(val.Num().Uint64()) {
+				return valueError(column, val.FloatString(decimalScale), field)
+			}
+			f.SetUint(val.Num().Uint64())
+			return nil
+		}
+	case reflect.String:
+		set = func(f reflect.Value, val *big.Rat) error {
+			f.SetString(val.FloatString(decimalScale))
+			return nil
+		}
+	default:
+		return nil, conversionError(column, vt, field)
+	}
+	return func(r *bytes.Buffer, f reflect.Value) error {
+		val, err := readDecimal(r)
+		if err != nil {
+			return err
+		}
+		if val == nil {
+			f.Set(reflect.Zero(field.Type))
+			return nil
+		}
+		return set(f, val)
+	}, nil
+}
+
+// tableDecoder stores embedded VoltTables in Table fields; *Table
+// fields are handled by the pointer rule of fieldDecoder.
+func tableDecoder(column string, vt int8, field reflect.StructField, loc *time.Location) (decodeFunc, error) {
+	if field.Type != tableType {
+		return nil, conversionError(column, vt, field)
+	}
+	return func(r *bytes.Buffer, f reflect.Value) error {
+		val, err := deserializeTable(r)
+		if err != nil {
+			return err
+		}
+		val.location = loc
+		f.Set(reflect.ValueOf(val))
+		return nil
+	}, nil
+}
+
+// geographyDecoder stores GEOGRAPHY_POINT and GEOGRAPHY columns in
+// GeographyPoint and Geography fields, or in strings as WKT.
+func geographyDecoder(column string, vt int8, field reflect.StructField) (decodeFunc, error) {
+	t := geographyPointType
+	if vt == vt_GEOGRAPHY {
+		t = geographyType
+	}
+	if field.Type != t && field.Type.Kind() != reflect.String {
+		return nil, conversionError(column, vt, field)
+	}
+	return func(r *bytes.Buffer, f reflect.Value) error {
+		val, err := readValue(r, vt, nil)
+		if err != nil {
+			return err
+		}
+		switch {
+		case val == nil:
+			f.Set(reflect.Zero(field.Type))
+		case field.Type.Kind() == reflect.String:
+			f.SetString(val.(fmt.Stringer).String())
+		default:
+			f.Set(reflect.ValueOf(val))
+		}
+		return nil
+	}, nil
+}
@@ -0,0 +1,155 @@
+package voltdb
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+)
+
+// cursor.go provides rewinding and random access over a Table's rows.
+// The row data of a table is never modified; a Table only tracks its
+// read position, so any number of cursors can share the same rows.
+
+// rowIndex holds the offset of each row within the table data. It is
+// built on first use and shared by all cursors of a table.
+type rowIndex struct {
+	once    sync.Once
+	offsets []int
+	err     error
+}
+
+func (table *Table) rowOffsets() ([]int, error) {
+	if table.index == nil {
+		table.index = new(rowIndex)
+	}
+	idx := table.index
+	idx.once.Do(func() {
+		idx.offsets = make([]int, 0, table.rowCount)
+		for pos := 0; pos < len(table.data); {
+			if len(table.data)-pos < 4 {
+				idx.err = fmt.Errorf("Truncated row header at offset %d.", pos)
+				return
+			}
+			length := int(int32(order.Uint32(table.data[pos:])))
+			if length < 0 {
+				idx.err = fmt.Errorf("Invalid length %d of row %d.", length, len(idx.offsets))
+				return
+			}
+			idx.offsets = append(idx.offsets, pos)
+			pos += 4 + length
+			if pos > len(table.data) {
+				idx.err = fmt.Errorf("Truncated row %d.", len(idx.offsets)-1)
+				return
+			}
+		}
+	})
+	return idx.offsets, idx.err
+}
+
+// Reset rewinds the table so that the next row read is the first.
+func (table *Table) Reset() {
+	table.rows = *bytes.NewBuffer(table.data)
+	table.position = 0
+}
+
+// Position returns the index of the next row to be read.
+func (table *Table) Position() int {
+	return table.position
+}
+
+// Seek positions the table so that the next row read is row i.
+func (table *Table) Seek(i int) error {
+	offsets, err := table.rowOffsets()
+	if err != nil {
+		return err
+	}
+	if i < 0 || i >= len(offsets) {
+		return fmt.Errorf("Row %d out of range [0, %d).", i, len(offsets))
+	}
+	table.rows = *bytes.NewBuffer(table.data[offsets[i]:])
+	table.position = i
+	return nil
+}
+
+// Cursor returns an independent cursor over the table's rows,
+// positioned at the first row. A Table is not safe for concurrent use
+// but each of its cursors may be used by a different goroutine.
+func (table *Table) Cursor() *Table {
+	if table.index == nil {
+		table.index = new(rowIndex)
+	}
+	cursor := *table
+	cursor.Reset()
+	return &cursor
+}
+
+// rowBytes returns row i, including its length header.
+func (table *Table) rowBytes(i int) ([]byte, error) {
+	offsets, err := table.rowOffsets()
+	if err != nil {
+		return nil, err
+	}
+	if i < 0 || i >= len(offsets) {
+		return nil, fmt.Errorf("Row %d out of range [0, %d).", i, len(offsets))
+	}
+	end := len(table.data)
+	if i+1 < len(offsets) {
+		end = offsets[i+1]
+	}
+	return table.data[offsets[i]:end], nil
+}
+
+// Row is a single row of a Table, returned by Table.Row.
+type Row struct {
+	table   *Table
+	index   int
+	raw     []byte // the row including its length header
+	offsets []int  // of each column in raw, found on first use
+}
+
+// Row returns row i without moving the table's read position.
+func (table *Table) Row(i int) (*Row, error) {
+	raw, err := table.rowBytes(i)
+	if err != nil {
+		return nil, err
+	}
+	return &Row{table: table, index: i, raw: raw}, nil
+}
+
+// NextRow returns the next row and advances the read position past it.
+func (table *Table) NextRow() (*Row, error) {
+	data := table.rows.Bytes()
+	r, err := table.startRow()
+	if err != nil {
+		return nil, err
+	}
+	return &Row{table: table, index: table.position - 1, raw: data[:4+r.Len()]}, nil
+}
+
+// Index returns the row's index within its table.
+func (row *Row) Index() int {
+	return row.index
+}
+
+// Decode populates v (*struct) with the row, as Table.Next does.
+func (row *Row) Decode(v interface{}) error {
+	return row.cursor().Next(v)
+}
+
+// Scan stores the row into dest, as Table.Scan does.
+func (row *Row) Scan(dest ...interface{}) error {
+	return row.cursor().Scan(dest...)
+}
+
+// Values returns the row's values, as Table.NextValues does.
+func (row *Row) Values() ([]interface{}, error) {
+	return row.cursor().NextValues()
+}
+
+// cursor returns a cursor whose only row is this row.
+func (row *Row) cursor() *Table {
+	cursor := *row.table
+	cursor.rows = *bytes.NewBuffer(row.raw)
+	cursor.position = row.index
+	return &cursor
+}
@@ -0,0 +1,101 @@
+package voltdb
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+type cursorRow struct {
+	Key   string
+	Value int32
+}
+
+func cursorTestTable(t *testing.T) *Table {
+	return testBuilder(t, []string{"KEY", "VALUE"}, []ColumnType{TypeString, TypeInteger},
+		[]interface{}{"a", 1},
+		[]interface{}{"bb", 2},
+		[]interface{}{"ccc", 3}).Build()
+}
+
+func TestResetAndPosition(t *testing.T) {
+	table := cursorTestTable(t)
+	var row cursorRow
+	for pass := 0; pass < 2; pass++ {
+		count := 0
+		for table.HasNext() {
+			if table.Position() != count {
+				t.Errorf("Bad position. Have %v expected %v", table.Position(), count)
+			}
+			if err := table.Next(&row); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			count++
+		}
+		if count != 3 || row.Key != "ccc" {
+			t.Errorf("Pass %v read %v rows ending at %#v", pass, count, row)
+		}
+		table.Reset()
+	}
+}
+
+func TestRowAndSeek(t *testing.T) {
+	table := cursorTestTable(t)
+	r, err := table.Row(1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var row cursorRow
+	if err = r.Decode(&row); err != nil || row.Key != "bb" || row.Value != 2 {
+		t.Errorf("Bad row %v %#v", err, row)
+	}
+	if table.Position() != 0 {
+		t.Errorf("Row moved the table position to %v", table.Position())
+	}
+	if _, err = table.Row(3); err == nil {
+		t.Errorf("Expected out of range error")
+	}
+	if err = table.Seek(2); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err = table.Next(&row); err != nil || row.Key != "ccc" || table.HasNext() {
+		t.Errorf("Bad row after seek %v %#v", err, row)
+	}
+}
+
+func TestConcurrentCursors(t *testing.T) {
+	table := cursorTestTable(t)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(cursor *Table) {
+			defer wg.Done()
+			var row cursorRow
+			var sum int32
+			for cursor.HasNext() {
+				if err := cursor.Next(&row); err != nil {
+					t.Errorf("Unexpected error: %v", err)
+					return
+				}
+				sum += row.Value
+			}
+			if _, err := cursor.Row(2); err != nil || sum != 6 {
+				t.Errorf("Bad cursor result %v %v", err, sum)
+			}
+		}(table.Cursor())
+	}
+	wg.Wait()
+}
+
+func TestRowOffsetsRejectsNegativeLength(t *testing.T) {
+	for _, length := range []int32{-4, -1, -100} {
+		var data bytes.Buffer
+		writeInt(&data, length)
+		writeInt(&data, 0)
+		table := &Table{columnCount: 1, columnTypes: []int8{vt_INT}, columnNames: []string{"A"},
+			rowCount: 1, data: data.Bytes()}
+		if _, err := table.Row(0); err == nil {
+			t.Errorf("Expected error for row length %d", length)
+		}
+	}
+}
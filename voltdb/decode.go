@@ -1,13 +1,32 @@
 package voltdb
 
 import (
+	"bytes"
+	"container/list"
 	"fmt"
+	"io"
 	"reflect"
+	"strings"
+	"sync"
+	"time"
 )
 
 // Internal methods to unmarshal / reflect a returned table []byte
 // into a slice of user provided row structs.
 
+// Struct fields receive the column named by their `volt:"COLUMN_NAME"`
+// tag or, untagged, the column matching the field name ignoring case.
+// Fields tagged `volt:"-"` and unexported fields are never set, and
+// columns without a matching field are skipped.
+//
+// The fields of embedded structs are flattened into the outer struct,
+// with Go's rules for promoted fields deciding between duplicate
+// names: the shallowest field wins, then a tagged one, and fields left
+// ambiguous receive no column. A struct field tagged
+// `volt:"addr_,prefix"` is populated from the columns named by its
+// fields' names prefixed with "addr_".
+const tagName = "volt"
+
 func (table *Table) next(v interface{}) error {
 	// iterate and assign the fields from data
 	// must have a pointer to be modifiable
@@ -23,55 +42,408 @@ func (table *Table) next(v interface{}) error {
 		return fmt.Errorf("Must supply a struct to populate with row data.")
 	}
 
-	if structVal.NumField() != int(table.columnCount) {
-		return fmt.Errorf("Must supply one field per column.")
+	plan, err := table.decodePlan(typeOfT)
+	if err != nil {
+		return err
+	}
+	if table.strict && plan.missing != "" {
+		return fmt.Errorf("No column %v for field %v.", plan.missing, plan.missingField)
 	}
 
+	r, err := table.startRow()
+	if err != nil {
+		return err
+	}
+
+	for _, op := range plan.ops {
+		if op.field == nil {
+			err = skipValue(r, op.vt)
+		} else if len(op.field) == 1 {
+			err = op.decode(r, structVal.Field(op.field[0]))
+		} else {
+			err = op.decode(r, fieldByIndex(structVal, op.field))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return table.endRow(r)
+}
+
+// fieldByIndex returns the nested field of v at index, allocating nil
+// embedded struct pointers along the way.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, idx := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(idx)
+	}
+	return v
+}
+
+// startRow consumes the next row and returns a reader of its columns.
+// Decoding a row reads only from that reader, so a row that fails to
+// decode leaves the table positioned at the next row.
+func (table *Table) startRow() (*bytes.Buffer, error) {
 	// stupid alias to type a bit less...
 	r := &table.rows
 
 	// each row has a 4 byte length
 	rowLength, err := readInt(r)
 	if err != nil {
-		return err
+		return nil, err
 	} else if rowLength <= 0 {
-		return fmt.Errorf("No more row data.")
+		return nil, fmt.Errorf("No more row data.")
+	} else if int(rowLength) > r.Len() {
+		return nil, fmt.Errorf("Truncated row %d.", table.position)
+	}
+	table.position++
+	return bytes.NewBuffer(r.Next(int(rowLength))), nil
+}
+
+// endRow checks that decoding row r consumed all of it.
+func (table *Table) endRow(r *bytes.Buffer) error {
+	if r.Len() != 0 {
+		return fmt.Errorf("Row %d has %d bytes after its last column.", table.position-1, r.Len())
+	}
+	return nil
+}
+
+// A decodePlan records how each column of a table schema is stored
+// into a struct type, so that reflection over the struct happens once
+// per (type, schema) rather than once per row.
+type decodePlan struct {
+	ops []columnOp
+	// the first field without a column, reported in strict mode.
+	missing      string
+	missingField string
+}
+
+type columnOp struct {
+	vt     int8
+	field  []int // the field's index path; nil skips the column
+	decode decodeFunc
+}
+
+type planKey struct {
+	typ    reflect.Type
+	schema string
+	loc    *time.Location
+}
+
+// planCacheSize bounds the plans shared by all tables, as ad hoc
+// queries may produce any number of schemas.
+const planCacheSize = 256
+
+// planCache holds the most recently used decodePlans.
+var planCache = newPlanLRU(planCacheSize)
+
+// planLRU is a map of decodePlans that evicts the least recently used
+// plan beyond its size.
+type planLRU struct {
+	mu    sync.Mutex
+	size  int
+	order *list.List // of *planEntry, most recent first
+	plans map[planKey]*list.Element
+}
+
+type planEntry struct {
+	key  planKey
+	plan *decodePlan
+}
+
+func newPlanLRU(size int) *planLRU {
+	return &planLRU{size: size, order: list.New(), plans: make(map[planKey]*list.Element)}
+}
+
+func (c *planLRU) Load(key planKey) (*decodePlan, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.plans[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*planEntry).plan, true
+}
+
+// LoadOrStore returns the plan cached for key, caching plan if there
+// is none.
+func (c *planLRU) LoadOrStore(key planKey, plan *decodePlan) *decodePlan {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.plans[key]; ok {
+		c.order.MoveToFront(elem)
+		return elem.Value.(*planEntry).plan
+	}
+	c.plans[key] = c.order.PushFront(&planEntry{key, plan})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.plans, oldest.Value.(*planEntry).key)
+	}
+	return plan
+}
+
+func (c *planLRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// decodePlan returns the cached plan for decoding table rows into
+// typeOfT, building it on first use.
+func (table *Table) decodePlan(typeOfT reflect.Type) (*decodePlan, error) {
+	if table.plan != nil && table.planType == typeOfT {
+		return table.plan, nil
+	}
+	key := planKey{typeOfT, table.schemaKey(), table.location}
+	if cached, ok := planCache.Load(key); ok {
+		table.plan, table.planType = cached, typeOfT
+		return table.plan, nil
 	}
+	plan, err := table.buildPlan(typeOfT)
+	if err != nil {
+		return nil, err
+	}
+	table.plan, table.planType = planCache.LoadOrStore(key, plan), typeOfT
+	return table.plan, nil
+}
 
+// schemaKey identifies the table's column names and types.
+func (table *Table) schemaKey() string {
+	var key strings.Builder
 	for idx, vt := range table.columnTypes {
-		structField := structVal.Field(idx)
-		switch vt {
-		case vt_BOOL:
-			val, _ := readBoolean(r)
-			structField.SetBool(val)
-		case vt_SHORT:
-			val, _ := readShort(r)
-			structField.SetInt(int64(val))
-		case vt_INT:
-			val, _ := readInt(r)
-			structField.SetInt(int64(val))
-		case vt_LONG:
-			val, _ := readLong(r)
-			structField.SetInt(val)
-		case vt_FLOAT:
-			val, _ := readFloat(r)
-			structField.SetFloat(val)
-		case vt_STRING:
-			val, _ := readString(r)
-			structField.SetString(val)
-		case vt_TIMESTAMP:
-			val, _ := readTimestamp(r)
-			structField.Set(reflect.ValueOf(val))
-		case vt_TABLE:
-			panic("Can not deserialize embedded tables.")
-		case vt_DECIMAL:
-			panic("Can not deserialize decimals yet.")
-		case vt_VARBIN:
-			panic("Can not deserialize varbinary yet.")
-		default:
-			panic("Unknown type in deserialize type")
+		key.WriteByte(byte(vt))
+		key.WriteString(table.columnNames[idx])
+		key.WriteByte(0)
+	}
+	return key.String()
+}
+
+func (table *Table) buildPlan(typeOfT reflect.Type) (*decodePlan, error) {
+	leaves := rowFields(typeOfT)
+	fields := table.mapColumns(leaves)
+	plan := &decodePlan{ops: make([]columnOp, len(fields))}
+	mapped := make(map[int]bool)
+	for col, leaf := range fields {
+		op := columnOp{vt: table.columnTypes[col]}
+		if leaf >= 0 {
+			var err error
+			op.field = leaves[leaf].index
+			op.decode, err = fieldDecoder(table.columnNames[col], op.vt, leaves[leaf].field, table.location)
+			if err != nil {
+				return nil, err
+			}
+			mapped[leaf] = true
 		}
+		plan.ops[col] = op
 	}
 
-	return nil
+	for idx, leaf := range leaves {
+		if !mapped[idx] {
+			plan.missing, plan.missingField = leaf.name, leaf.field.Name
+			break
+		}
+	}
+	return plan, nil
+}
+
+// rowField is a struct field, possibly nested in embedded or prefixed
+// structs, that receives a column.
+type rowField struct {
+	name   string // the column name
+	index  []int  // the index path from the outer struct
+	field  reflect.StructField
+	tagged bool
+}
+
+// rowFields returns the fields of a row struct type that receive
+// columns, in declaration order. Of fields with the same column name,
+// only the dominant field is kept.
+func rowFields(t reflect.Type) []rowField {
+	var all []rowField
+	var depths []int
+	var walk func(t reflect.Type, prefix string, index []int, visiting map[reflect.Type]bool)
+	walk = func(t reflect.Type, prefix string, index []int, visiting map[reflect.Type]bool) {
+		visiting[t] = true
+		defer delete(visiting, t)
+		for idx := 0; idx < t.NumField(); idx++ {
+			field := t.Field(idx)
+			path := append(append([]int(nil), index...), idx)
+			tag := field.Tag.Get(tagName)
+			if tag == "-" {
+				continue
+			}
+			name, opts, _ := strings.Cut(tag, ",")
+
+			inner := field.Type
+			if inner.Kind() == reflect.Ptr {
+				inner = inner.Elem()
+			}
+			nested := inner.Kind() == reflect.Struct && !visiting[inner] && !isLeafType(inner)
+			if nested && field.Anonymous && name == "" {
+				if field.PkgPath != "" && field.Type.Kind() == reflect.Ptr {
+					// a nil pointer to an unexported type can't be allocated.
+					continue
+				}
+				walk(inner, prefix, path, visiting)
+				continue
+			}
+			if nested && opts == "prefix" && field.PkgPath == "" {
+				walk(inner, prefix+name, path, visiting)
+				continue
+			}
+			if field.PkgPath != "" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			all = append(all, rowField{prefix + name, path, field, tag != ""})
+			depths = append(depths, len(path))
+		}
+	}
+	walk(t, "", nil, make(map[reflect.Type]bool))
+
+	byName := make(map[string][]int)
+	for idx, f := range all {
+		key := strings.ToUpper(f.name)
+		byName[key] = append(byName[key], idx)
+	}
+	fields := make([]rowField, 0, len(byName))
+	for idx, f := range all {
+		if dominantField(byName[strings.ToUpper(f.name)], all, depths) == idx {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// dominantField returns the one of the same-named fields at candidates
+// that receives their column, or -1 if they are ambiguous. As for Go's
+// promoted fields, the shallowest wins; at equal depth a single tagged
+// field wins, and otherwise none does.
+func dominantField(candidates []int, all []rowField, depths []int) int {
+	shallowest := depths[candidates[0]]
+	for _, idx := range candidates {
+		shallowest = min(shallowest, depths[idx])
+	}
+	dominant, count, tagged := -1, 0, 0
+	for _, idx := range candidates {
+		if depths[idx] != shallowest {
+			continue
+		}
+		count++
+		if all[idx].tagged {
+			tagged++
+			dominant = idx
+		} else if count == 1 {
+			dominant = idx
+		}
+	}
+	if tagged == 1 || (tagged == 0 && count == 1) {
+		return dominant
+	}
+	return -1
+}
+
+// isLeafType reports whether struct type t is decoded as one value
+// rather than having its fields populated from columns.
+func isLeafType(t reflect.Type) bool {
+	ptr := reflect.PointerTo(t)
+	switch t {
+	case timeType, tableType, geographyPointType, geographyType:
+		return true
+	}
+	return ptr == ratPtrType || ptr.Implements(unmarshalerType) || ptr.Implements(scannerType)
+}
+
+// mapColumns returns, for each column, the index in fields of the
+// field that receives it or -1 if the column is skipped.
+//
+// For compatibility with structs written before columns were matched
+// by name, a struct with no volt tags, no field matching any column
+// and exactly one field per column is populated in column order.
+func (table *Table) mapColumns(fields []rowField) []int {
+	byName := make(map[string]int, len(fields))
+	tagged := false
+	for idx, f := range fields {
+		byName[strings.ToUpper(f.name)] = idx
+		tagged = tagged || f.tagged
+	}
+
+	columns := make([]int, len(table.columnNames))
+	matched := 0
+	for col, colName := range table.columnNames {
+		columns[col] = -1
+		if idx, ok := byName[strings.ToUpper(colName)]; ok {
+			columns[col] = idx
+			delete(byName, strings.ToUpper(colName))
+			matched++
+		}
+	}
+
+	if matched == 0 && !tagged && len(fields) == len(table.columnTypes) {
+		for col := range columns {
+			columns[col] = col
+		}
+	}
+	return columns
+}
+
+// skipValue advances r past one value of volt type vt.
+func skipValue(r *bytes.Buffer, vt int8) error {
+	_, err := readRaw(r, vt)
+	return err
+}
+
+// readRaw returns the wire bytes of one value of volt type vt, without
+// the length prefix of variable length types, or nil for NULL. The
+// bytes are not copied.
+func readRaw(r *bytes.Buffer, vt int8) ([]byte, error) {
+	var size int
+	switch vt {
+	case vt_TINYINT, vt_BOOLEAN:
+		size = 1
+	case vt_SHORT:
+		size = 2
+	case vt_INT:
+		size = 4
+	case vt_LONG, vt_FLOAT, vt_TIMESTAMP:
+		size = 8
+	case vt_DECIMAL:
+		size = 16
+	case vt_GEOPOINT:
+		size = 16
+	case vt_STRING, vt_VARBIN, vt_GEOGRAPHY:
+		length, err := readInt(r)
+		if err != nil {
+			return nil, err
+		}
+		if length < 0 {
+			return nil, nil
+		}
+		size = int(length)
+	case vt_TABLE:
+		length, err := readInt(r)
+		if err != nil {
+			return nil, err
+		}
+		size = int(length)
+	default:
+		return nil, fmt.Errorf("Unknown type %d in column data.", vt)
+	}
+	if size < 0 || r.Len() < size {
+		return nil, io.ErrUnexpectedEOF
+	}
+	raw := r.Next(size)
+	if isNull(raw, vt) && vt != vt_STRING && vt != vt_VARBIN && vt != vt_GEOGRAPHY {
+		return nil, nil
+	}
+	return raw, nil
 }
@@ -0,0 +1,564 @@
+package voltdb
+
+import (
+	"bytes"
+	"database/sql"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testTable builds a Table holding rows of values serialized as types.
+func testTable(names []string, types []int8, rows ...[]interface{}) *Table {
+	t := &Table{
+		columnCount: int16(len(names)),
+		columnTypes: types,
+		columnNames: names,
+		rowCount:    int32(len(rows)),
+	}
+	var data bytes.Buffer
+	for _, row := range rows {
+		var buf bytes.Buffer
+		for idx, val := range row {
+			if val == nil {
+				encodeNull(&buf, names[idx], types[idx])
+				continue
+			}
+			switch types[idx] {
+			case vt_TINYINT:
+				writeByte(&buf, val.(int8))
+			case vt_BOOLEAN:
+				writeBoolean(&buf, val.(bool))
+			case vt_SHORT:
+				writeShort(&buf, val.(int16))
+			case vt_INT:
+				writeInt(&buf, val.(int32))
+			case vt_LONG:
+				writeLong(&buf, val.(int64))
+			case vt_FLOAT:
+				writeFloat(&buf, val.(float64))
+			case vt_STRING:
+				writeString(&buf, val.(string))
+			case vt_TIMESTAMP:
+				writeTimestamp(&buf, val.(time.Time), TimestampError)
+			case vt_VARBIN:
+				writeByteString(&buf, val.([]byte))
+			case vt_DECIMAL:
+				buf.Write(val.([]byte))
+			case vt_TABLE:
+				serializeTable(&buf, val.(*Table))
+			}
+		}
+		writeInt(&data, int32(buf.Len()))
+		data.Write(buf.Bytes())
+	}
+	t.data = data.Bytes()
+	t.Reset()
+	return t
+}
+
+// testBuilder returns a TableBuilder with columns of types holding rows.
+func testBuilder(t *testing.T, names []string, types []ColumnType, rows ...[]interface{}) *TableBuilder {
+	b := NewTableBuilder()
+	for idx, name := range names {
+		if err := b.AddColumn(name, types[idx]); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	for _, row := range rows {
+		if err := b.AddRow(row...); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	return b
+}
+
+func TestNextByColumnName(t *testing.T) {
+	table := testTable(
+		[]string{"HOST_ID", "PROCEDURE", "INVOCATIONS", "EXTRA"},
+		[]int8{vt_INT, vt_STRING, vt_LONG, vt_STRING},
+		[]interface{}{int32(7), "Vote", int64(100), "ignored"})
+
+	type Row struct {
+		Invocations int64
+		Procedure   string
+		Host        int32  `volt:"HOST_ID"`
+		Skipped     string `volt:"-"`
+		unexported  int
+	}
+	row := Row{Skipped: "keep"}
+	if err := table.Next(&row); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if row.Invocations != 100 || row.Procedure != "Vote" || row.Host != 7 {
+		t.Errorf("Bad row %#v", row)
+	}
+	if row.Skipped != "keep" {
+		t.Errorf("Skipped field was overwritten: %v", row.Skipped)
+	}
+	if table.HasNext() {
+		t.Errorf("Expected unmatched columns to be consumed")
+	}
+}
+
+type TestAudit struct {
+	CreatedBy string
+	Version   int32 `volt:"ROW_VERSION"`
+}
+
+type testAddress struct {
+	Street string
+	City   string
+}
+
+func TestNextNestedStructs(t *testing.T) {
+	table := testTable(
+		[]string{"ID", "CREATEDBY", "ROW_VERSION", "HOME_STREET", "HOME_CITY", "WORK_CITY"},
+		[]int8{vt_INT, vt_STRING, vt_INT, vt_STRING, vt_STRING, vt_STRING},
+		[]interface{}{int32(1), "admin", int32(3), "1 Main St", "Boston", "Cambridge"})
+
+	type Row struct {
+		*TestAudit
+		ID   int32
+		Home testAddress  `volt:"HOME_,prefix"`
+		Work *testAddress `volt:"WORK_,prefix"`
+	}
+	var row Row
+	if err := table.Next(&row); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if row.TestAudit == nil || row.CreatedBy != "admin" || row.Version != 3 {
+		t.Errorf("Bad embedded fields %#v", row.TestAudit)
+	}
+	if row.ID != 1 || row.Home.Street != "1 Main St" || row.Home.City != "Boston" {
+		t.Errorf("Bad row %#v", row)
+	}
+	if row.Work == nil || row.Work.City != "Cambridge" || row.Work.Street != "" {
+		t.Errorf("Bad prefixed pointer field %#v", row.Work)
+	}
+}
+
+func TestNextEmbeddedShadowed(t *testing.T) {
+	table := testTable(
+		[]string{"CREATEDBY"},
+		[]int8{vt_STRING},
+		[]interface{}{"outer"})
+
+	type Row struct {
+		TestAudit
+		CreatedBy string
+	}
+	var row Row
+	if err := table.Next(&row); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if row.CreatedBy != "outer" || row.TestAudit.CreatedBy != "" {
+		t.Errorf("Expected outer field to shadow embedded field %#v", row)
+	}
+}
+
+func TestNextEmbeddedAmbiguous(t *testing.T) {
+	table := testTable(
+		[]string{"NAME", "CODE"},
+		[]int8{vt_STRING, vt_STRING},
+		[]interface{}{"n", "c"})
+
+	type First struct {
+		Name string
+		Code string
+	}
+	type Second struct {
+		Name string
+		Code string `volt:"CODE"`
+	}
+	type Row struct {
+		First
+		Second
+	}
+	var row Row
+	if err := table.Next(&row); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if row.First.Name != "" || row.Second.Name != "" {
+		t.Errorf("Expected ambiguous fields to be left unset %#v", row)
+	}
+	if row.First.Code != "" || row.Second.Code != "c" {
+		t.Errorf("Expected the tagged field to win %#v", row)
+	}
+}
+
+func TestNextStrict(t *testing.T) {
+	table := testTable([]string{"KEY"}, []int8{vt_STRING}, []interface{}{"a"})
+	type Row struct {
+		Key   string
+		Value string
+	}
+	var row Row
+	table.SetStrict(true)
+	if err := table.Next(&row); err == nil {
+		t.Errorf("Expected strict error for field without a column")
+	}
+	table.SetStrict(false)
+	if err := table.Next(&row); err != nil || row.Key != "a" {
+		t.Errorf("Unexpected result %v %#v", err, row)
+	}
+}
+
+func TestNextColumnOrderFallback(t *testing.T) {
+	table := testTable([]string{"CONTESTANT_NAME", "TOTAL_VOTES"},
+		[]int8{vt_STRING, vt_LONG}, []interface{}{"Bob", int64(3)})
+	type Row struct {
+		Contestant string
+		Votes      int64
+	}
+	var row Row
+	if err := table.Next(&row); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if row.Contestant != "Bob" || row.Votes != 3 {
+		t.Errorf("Bad row %#v", row)
+	}
+}
+
+func TestDecodePlanCached(t *testing.T) {
+	type Row struct {
+		Key   string
+		Value string
+	}
+	names := []string{"KEY", "VALUE"}
+	types := []int8{vt_STRING, vt_STRING}
+	first := testTable(names, types, []interface{}{"a", "b"})
+	second := testTable(names, types, []interface{}{"c", "d"})
+	var row Row
+	first.Next(&row)
+	second.Next(&row)
+	if first.plan == nil || first.plan != second.plan {
+		t.Errorf("Expected tables with one schema to share a decode plan")
+	}
+	if row.Key != "c" || row.Value != "d" {
+		t.Errorf("Bad row %#v", row)
+	}
+}
+
+func TestDecodePlanCacheBounded(t *testing.T) {
+	cache := newPlanLRU(2)
+	keys := []planKey{{schema: "a"}, {schema: "b"}, {schema: "c"}}
+	plans := []*decodePlan{{}, {}, {}}
+	cache.LoadOrStore(keys[0], plans[0])
+	cache.LoadOrStore(keys[1], plans[1])
+	cache.Load(keys[0])
+	if cached := cache.LoadOrStore(keys[2], plans[2]); cached != plans[2] {
+		t.Errorf("Expected the new plan to be cached")
+	}
+	if cache.Len() != 2 {
+		t.Errorf("Expected 2 cached plans, have %d", cache.Len())
+	}
+	if _, ok := cache.Load(keys[1]); ok {
+		t.Errorf("Expected the least recently used plan to be evicted")
+	}
+	if cached, ok := cache.Load(keys[0]); !ok || cached != plans[0] {
+		t.Errorf("Expected the recently used plan to be kept")
+	}
+}
+
+// benchmarkTable builds a synthetic @AdHoc result of many rows.
+func benchmarkTable(rowCount int) *Table {
+	names := []string{"ID", "NAME", "SCORE", "CREATED", "COUNTER", "NOTES"}
+	types := []int8{vt_LONG, vt_STRING, vt_FLOAT, vt_TIMESTAMP, vt_INT, vt_STRING}
+	rows := make([][]interface{}, rowCount)
+	created := time.Unix(1400000000, 0)
+	for idx := range rows {
+		rows[idx] = []interface{}{int64(idx), "contestant", float64(idx) / 3,
+			created.Add(time.Duration(idx) * time.Second), int32(idx), "some notes"}
+	}
+	return testTable(names, types, rows...)
+}
+
+func benchmarkNext(b *testing.B, v interface{}) {
+	table := benchmarkTable(200000)
+	b.SetBytes(int64(len(table.data) / table.RowCount()))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !table.HasNext() {
+			table.Reset()
+		}
+		if err := table.Next(v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkNextAllColumns(b *testing.B) {
+	var row struct {
+		Id      int64
+		Name    string
+		Score   float64
+		Created time.Time
+		Counter int32
+		Notes   string
+	}
+	benchmarkNext(b, &row)
+}
+
+func BenchmarkNextSomeColumns(b *testing.B) {
+	var row struct {
+		Id    int64
+		Score float64
+	}
+	benchmarkNext(b, &row)
+}
+
+func TestNextConversions(t *testing.T) {
+	ts := time.Unix(1400000000, 5000)
+	// 12.5 as a decimal: 12500000000000 unscaled.
+	decimal := make([]byte, 16)
+	order.PutUint64(decimal[8:], 12500000000000)
+	table := testTable(
+		[]string{"ID", "COUNT", "TS", "TS_MICROS", "NAME", "BIN", "PRICE", "PRICE_STR"},
+		[]int8{vt_LONG, vt_INT, vt_TIMESTAMP, vt_TIMESTAMP, vt_STRING, vt_VARBIN,
+			vt_DECIMAL, vt_DECIMAL},
+		[]interface{}{int64(42), int32(7), ts, ts, "abc", []byte{1, 2},
+			decimal, decimal})
+
+	type Row struct {
+		Id       string
+		Count    float64
+		Ts       time.Time
+		TsMicros int64 `volt:"TS_MICROS"`
+		Name     []byte
+		Bin      []byte
+		Price    float64
+		PriceStr string `volt:"PRICE_STR"`
+	}
+	var row Row
+	if err := table.Next(&row); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if row.Id != "42" || row.Count != 7 || !row.Ts.Equal(ts) ||
+		row.TsMicros != ts.UnixNano()/1000 || string(row.Name) != "abc" ||
+		len(row.Bin) != 2 || row.Price != 12.5 || row.PriceStr != "12.500000000000" {
+		t.Errorf("Bad conversions %#v", row)
+	}
+}
+
+func TestNextOverflow(t *testing.T) {
+	table := testTable([]string{"BIG"}, []int8{vt_LONG}, []interface{}{int64(1 << 40)})
+	var row struct{ Big int16 }
+	err := table.Next(&row)
+	if err == nil || !strings.Contains(err.Error(), "BIG") ||
+		!strings.Contains(err.Error(), "Big") {
+		t.Errorf("Expected overflow error naming column and field. Have %v", err)
+	}
+}
+
+func TestNextImpossibleConversion(t *testing.T) {
+	table := testTable([]string{"NAME"}, []int8{vt_STRING}, []interface{}{"abc"})
+	var row struct{ Name int }
+	err := table.Next(&row)
+	if err == nil || !strings.Contains(err.Error(), "NAME") {
+		t.Errorf("Expected conversion error naming the column. Have %v", err)
+	}
+}
+
+func TestNextNulls(t *testing.T) {
+	table := testTable([]string{"A", "B", "C"}, []int8{vt_INT, vt_LONG, vt_FLOAT},
+		[]interface{}{int32(math.MinInt32), int64(math.MinInt64), -1.7e308})
+	one := 1
+	row := struct {
+		A *int
+		B int64
+		C interface{}
+	}{A: &one, B: 5, C: 1.5}
+	if err := table.Next(&row); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if row.A != nil || row.B != 0 || row.C != nil {
+		t.Errorf("Expected NULLs as nil or zero values. Have %#v", row)
+	}
+}
+
+func TestNextEmbeddedTable(t *testing.T) {
+	inner := testTable(
+		[]string{"KEY", "VALUE"},
+		[]int8{vt_STRING, vt_INT},
+		[]interface{}{"a", int32(1)},
+		[]interface{}{"b", int32(2)})
+	table := testTable(
+		[]string{"NAME", "DETAIL", "COPY", "ANY"},
+		[]int8{vt_STRING, vt_TABLE, vt_TABLE, vt_TABLE},
+		[]interface{}{"outer", inner, inner, inner})
+
+	var row struct {
+		Name   string
+		Detail *Table
+		Copy   Table
+		Any    interface{}
+	}
+	if err := table.Next(&row); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if row.Name != "outer" || table.HasNext() {
+		t.Errorf("Bad row %#v", row)
+	}
+	for _, embedded := range []*Table{row.Detail, &row.Copy, row.Any.(*Table)} {
+		if embedded.RowCount() != 2 || embedded.ColumnCount() != 2 {
+			t.Fatalf("Bad embedded table %v rows %v columns",
+				embedded.RowCount(), embedded.ColumnCount())
+		}
+		var kv struct {
+			Key   string
+			Value int32
+		}
+		for _, expected := range []int32{1, 2} {
+			if err := embedded.Next(&kv); err != nil || kv.Value != expected {
+				t.Errorf("Bad embedded row %v: %v", kv, err)
+			}
+		}
+	}
+}
+
+func TestMarshalTableParam(t *testing.T) {
+	inner := testTable(
+		[]string{"KEY", "VALUE"},
+		[]int8{vt_STRING, vt_INT},
+		[]interface{}{"a", int32(1)})
+	inner.Next(&struct{}{})
+
+	var buf bytes.Buffer
+	if err := marshalParam(&buf, inner, TimestampError); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if vt, _ := readByte(&buf); vt != vt_TABLE {
+		t.Fatalf("Bad parameter type %v", vt)
+	}
+	table, err := deserializeTable(&buf)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if buf.Len() != 0 || table.RowCount() != 1 || table.schemaKey() != inner.schemaKey() {
+		t.Errorf("Bad round trip of table %v", table.schemaKey())
+	}
+	var kv struct {
+		Key   string
+		Value int32
+	}
+	if err := table.Next(&kv); err != nil || kv.Key != "a" || kv.Value != 1 {
+		t.Errorf("Bad round trip row %v: %v", kv, err)
+	}
+
+	buf.Reset()
+	if err := marshalParam(&buf, (*Table)(nil), TimestampError); err != nil || buf.Bytes()[0] != byte(vt_NULL) {
+		t.Errorf("Expected nil table to marshal as NULL: %v", err)
+	}
+}
+
+func TestNextUnsignedAndBoolean(t *testing.T) {
+	table := testTable(
+		[]string{"SMALL", "BIG", "FLAG", "FLAGNAME", "FLAGINT"},
+		[]int8{vt_TINYINT, vt_LONG, vt_BOOLEAN, vt_BOOLEAN, vt_BOOLEAN},
+		[]interface{}{int8(100), int64(1 << 40), true, true, false},
+		[]interface{}{int8(-1), int64(0), false, false, false})
+
+	var row struct {
+		Small    uint8
+		Big      uint64
+		Flag     bool
+		FlagName string
+		FlagInt  int
+	}
+	if err := table.Next(&row); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if row.Small != 100 || row.Big != 1<<40 || !row.Flag || row.FlagName != "true" || row.FlagInt != 0 {
+		t.Errorf("Bad row %#v", row)
+	}
+	if err := table.Next(&row); err == nil {
+		t.Errorf("Expected error decoding a negative value into uint8")
+	}
+}
+
+func TestNextTimestampLocation(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("No time zone data: %v", err)
+	}
+	epoch := time.Unix(0, 0)
+	newTable := func() *Table {
+		return testTable([]string{"AT", "TEXT", "ANY", "NULLABLE", "MISSING"},
+			[]int8{vt_TIMESTAMP, vt_TIMESTAMP, vt_TIMESTAMP, vt_TIMESTAMP, vt_TIMESTAMP},
+			[]interface{}{epoch, epoch, epoch, epoch, epoch})
+	}
+	type Row struct {
+		At       time.Time
+		Text     string
+		Any      interface{}
+		Nullable sql.NullTime
+		Missing  *time.Time
+	}
+
+	var row Row
+	if err := newTable().Next(&row); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if row.At.Location() != time.UTC || row.Text != "1970-01-01T00:00:00Z" {
+		t.Errorf("Expected UTC by default. Have %v %v", row.At, row.Text)
+	}
+
+	// the same type and schema in another location uses another plan.
+	table := newTable()
+	table.location = ny
+	if err := table.Next(&row); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if row.At.Location() != ny || !row.At.Equal(epoch) || row.Text != "1969-12-31T19:00:00-05:00" ||
+		row.Any.(time.Time).Location() != ny {
+		t.Errorf("Expected New York times. Have %v %v %v", row.At, row.Text, row.Any)
+	}
+	if !row.Nullable.Valid || !row.Nullable.Time.Equal(epoch) || row.Missing == nil {
+		t.Errorf("Expected the epoch not to be NULL. Have %v %v", row.Nullable, row.Missing)
+	}
+}
+
+func TestNextTimestampNullIntoInt64(t *testing.T) {
+	type Row struct {
+		Micros int64
+	}
+	type NullableRow struct {
+		Micros *int64
+	}
+	newTable := func(val interface{}) *Table {
+		return testTable([]string{"MICROS"}, []int8{vt_TIMESTAMP}, []interface{}{val})
+	}
+
+	var row Row
+	if err := newTable(time.Unix(0, 0)).Next(&row); err != nil || row.Micros != 0 {
+		t.Errorf("Expected the epoch as 0. Have %v %v", row.Micros, err)
+	}
+	if err := newTable(nil).Next(&row); err == nil {
+		t.Errorf("Expected error reading a NULL TIMESTAMP into an int64")
+	}
+
+	var nullable NullableRow
+	if err := newTable(time.Unix(0, 0)).Next(&nullable); err != nil || nullable.Micros == nil || *nullable.Micros != 0 {
+		t.Errorf("Expected the epoch as 0. Have %v %v", nullable.Micros, err)
+	}
+	if err := newTable(nil).Next(&nullable); err != nil || nullable.Micros != nil {
+		t.Errorf("Expected NULL as nil. Have %v %v", nullable.Micros, err)
+	}
+
+	type NamedRow struct {
+		Micros int64
+		Name   string
+	}
+	table := testTable([]string{"MICROS", "NAME"}, []int8{vt_TIMESTAMP, vt_STRING},
+		[]interface{}{nil, "null"},
+		[]interface{}{time.UnixMicro(5), "five"})
+	var named NamedRow
+	if err := table.Next(&named); err == nil {
+		t.Errorf("Expected error reading a NULL TIMESTAMP into an int64")
+	}
+	if err := table.Next(&named); err != nil || named.Micros != 5 || named.Name != "five" {
+		t.Errorf("Bad row after the NULL TIMESTAMP. Have %v %v", named, err)
+	}
+}
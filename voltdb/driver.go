@@ -0,0 +1,414 @@
+package voltdb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// driver.go registers a database/sql driver named "voltdb". Statements
+// run with @AdHoc, binding ? or sql.Named parameters, except for
+//
+//	CALL Procedure(?, ?)
+//	EXEC Procedure ?, ?
+//
+// which call the stored procedure. Without a parameter list, CALL and
+// EXEC pass every argument; named arguments are ordered by the
+// procedure's parameter names, as by ParamsFromMap. VoltDB has no
+// client transactions, so Begin is an error.
+
+func init() {
+	sql.Register("voltdb", &Driver{})
+}
+
+// Driver is the database/sql driver. Open takes a DSN in the form
+// accepted by ParseDSN.
+type Driver struct{}
+
+// Open returns a new connection to the database named by dsn.
+func (d *Driver) Open(dsn string) (driver.Conn, error) {
+	connector, err := d.OpenConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return connector.Connect(context.Background())
+}
+
+// OpenConnector parses dsn once for every connection of a sql.DB.
+func (d *Driver) OpenConnector(dsn string) (driver.Connector, error) {
+	cfg, err := ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return NewConnector(cfg), nil
+}
+
+// NewConnector returns a connector for sql.OpenDB that dials cfg, for
+// configuration that a DSN can not express.
+func NewConnector(cfg *Config) driver.Connector {
+	return &connector{cfg: cfg}
+}
+
+type connector struct {
+	cfg *Config
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := DialContext(ctx, c.cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlConn{conn: conn}, nil
+}
+
+func (c *connector) Driver() driver.Driver {
+	return &Driver{}
+}
+
+// sqlConn adapts a Conn to driver.Conn.
+type sqlConn struct {
+	conn *Conn
+}
+
+func (c *sqlConn) Prepare(query string) (driver.Stmt, error) {
+	return &sqlStmt{conn: c, query: query}, nil
+}
+
+func (c *sqlConn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *sqlConn) Begin() (driver.Tx, error) {
+	return nil, fmt.Errorf("VoltDB does not support client transactions; use a stored procedure.")
+}
+
+// IsValid reports whether the Conn is open and connected or able to
+// reconnect.
+func (c *sqlConn) IsValid() bool {
+	c.conn.mu.Lock()
+	defer c.conn.mu.Unlock()
+	if c.conn.closed {
+		return false
+	}
+	return c.conn.netConn != nil || c.conn.cfg.Reconnect.MaxAttempts > 0
+}
+
+func (c *sqlConn) ResetSession(ctx context.Context) error {
+	if !c.IsValid() {
+		return driver.ErrBadConn
+	}
+	return nil
+}
+
+// Ping returns driver.ErrBadConn if the connection is lost and the
+// error otherwise, so that a done context does not discard a healthy
+// connection.
+func (c *sqlConn) Ping(ctx context.Context) error {
+	_, err := c.call(ctx, "@Ping", nil)
+	if err != nil && ctx.Err() == nil && !c.IsValid() {
+		return driver.ErrBadConn
+	}
+	return err
+}
+
+// CheckNamedValue accepts every argument; parameters are checked when
+// they are serialized.
+func (c *sqlConn) CheckNamedValue(nv *driver.NamedValue) error {
+	return nil
+}
+
+func (c *sqlConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	rsp, err := c.run(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return sqlResult{rsp}, nil
+}
+
+func (c *sqlConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	rsp, err := c.run(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	rows := &sqlRows{tables: rsp.tables, cursor: new(Table)}
+	if len(rows.tables) > 0 {
+		rows.cursor = rows.tables[0].Cursor()
+	}
+	return rows, nil
+}
+
+// run executes query as a procedure call or with @AdHoc.
+func (c *sqlConn) run(ctx context.Context, query string, args []driver.NamedValue) (*Response, error) {
+	procedure, params, err := c.invocation(query, args)
+	if err != nil {
+		return nil, err
+	}
+	rsp, err := c.call(ctx, procedure, params)
+	if err != nil && procedure != "@AdHoc" && len(args) > 0 && args[0].Name != "" {
+		// the parameters kept for named arguments may be out of date.
+		c.conn.forgetSignatures()
+	}
+	return rsp, err
+}
+
+// invocation returns the procedure and parameters that run query.
+func (c *sqlConn) invocation(query string, args []driver.NamedValue) (string, []interface{}, error) {
+	var named map[string]interface{}
+	values := make([]interface{}, len(args))
+	for idx, arg := range args {
+		if arg.Name == "" {
+			values[idx] = arg.Value
+			continue
+		}
+		if named == nil {
+			named = make(map[string]interface{})
+		}
+		named[arg.Name] = arg.Value
+	}
+	if named != nil && len(named) != len(args) {
+		return "", nil, fmt.Errorf("Arguments must be all named or all positional.")
+	}
+
+	procedure, placeholders, ok, err := parseCall(query)
+	if err != nil {
+		return "", nil, err
+	}
+	if ok {
+		if named != nil {
+			if placeholders >= 0 {
+				return "", nil, fmt.Errorf("Named arguments to %v can not have a parameter list.", procedure)
+			}
+			values, err = c.conn.ParamsFromMap(procedure, named)
+			return procedure, values, err
+		}
+		if placeholders >= 0 && placeholders != len(values) {
+			return "", nil, fmt.Errorf("Call of %v has %d placeholders for %d arguments.",
+				procedure, placeholders, len(values))
+		}
+		return procedure, values, nil
+	}
+
+	if named != nil {
+		if query, values, err = bindNamed(query, named); err != nil {
+			return "", nil, err
+		}
+	}
+	return "@AdHoc", append([]interface{}{query}, values...), nil
+}
+
+// call invokes procedure, bounded by the deadline of ctx and abandoned
+// if ctx is done first. A failed response is an error.
+func (c *sqlConn) call(ctx context.Context, procedure string, params []interface{}) (*Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if !c.IsValid() {
+		return nil, driver.ErrBadConn
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout := time.Until(deadline)
+		if timeout <= 0 {
+			return nil, context.DeadlineExceeded
+		}
+		params = append(params, WithTimeout(timeout))
+	}
+
+	type result struct {
+		rsp *Response
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		rsp, err := c.conn.Call(procedure, params...)
+		done <- result{rsp, err}
+	}()
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-done:
+		if res.err != nil {
+			return nil, res.err
+		}
+		if res.rsp.Status() != SUCCESS {
+			return nil, fmt.Errorf("%v failed with %v: %v", procedure, res.rsp.Status(), res.rsp.StatusString())
+		}
+		return res.rsp, nil
+	}
+}
+
+// parseCall recognizes "CALL proc(?, ...)" and "EXEC proc ?, ..." and
+// returns the procedure and the number of ? placeholders, or -1 when
+// there is no parameter list.
+func parseCall(query string) (procedure string, placeholders int, ok bool, err error) {
+	query = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(query), ";"))
+	keyword, rest, _ := strings.Cut(query, " ")
+	if !strings.EqualFold(keyword, "CALL") && !strings.EqualFold(keyword, "EXEC") {
+		return "", 0, false, nil
+	}
+	rest = strings.TrimSpace(rest)
+	end := strings.IndexFunc(rest, func(r rune) bool {
+		return r == '(' || r == ',' || r == '?' || r == ' ' || r == '\t' || r == '\n'
+	})
+	if end < 0 {
+		end = len(rest)
+	}
+	procedure, rest = rest[:end], strings.TrimSpace(rest[end:])
+	if procedure == "" {
+		return "", 0, false, fmt.Errorf("%v has no procedure name.", keyword)
+	}
+	if rest == "" {
+		return procedure, -1, true, nil
+	}
+	if strings.HasPrefix(rest, "(") && strings.HasSuffix(rest, ")") {
+		rest = strings.TrimSpace(rest[1 : len(rest)-1])
+		if rest == "" {
+			return procedure, 0, true, nil
+		}
+	}
+	for _, param := range strings.Split(rest, ",") {
+		if strings.TrimSpace(param) != "?" {
+			return "", 0, false, fmt.Errorf("Parameters of %v must be ? placeholders, have %q.",
+				procedure, strings.TrimSpace(param))
+		}
+		placeholders++
+	}
+	return procedure, placeholders, true, nil
+}
+
+// sqlStmt is a statement prepared by the client; VoltDB plans ad hoc
+// SQL as it runs.
+type sqlStmt struct {
+	conn  *sqlConn
+	query string
+}
+
+func (s *sqlStmt) Close() error {
+	return nil
+}
+
+func (s *sqlStmt) NumInput() int {
+	return -1
+}
+
+func (s *sqlStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), namedValues(args))
+}
+
+func (s *sqlStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValues(args))
+}
+
+func (s *sqlStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.conn.ExecContext(ctx, s.query, args)
+}
+
+func (s *sqlStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.conn.QueryContext(ctx, s.query, args)
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for idx, arg := range args {
+		named[idx] = driver.NamedValue{Ordinal: idx + 1, Value: arg}
+	}
+	return named
+}
+
+// sqlResult reports the rows modified by a statement, which VoltDB
+// returns as a last result table of one BIGINT named MODIFIED_TUPLES.
+type sqlResult struct {
+	rsp *Response
+}
+
+func (r sqlResult) LastInsertId() (int64, error) {
+	return 0, fmt.Errorf("VoltDB does not generate insert ids.")
+}
+
+func (r sqlResult) RowsAffected() (int64, error) {
+	if len(r.rsp.tables) == 0 {
+		return 0, nil
+	}
+	table := &r.rsp.tables[len(r.rsp.tables)-1]
+	if table.RowCount() != 1 || table.ColumnCount() != 1 || table.columnTypes[0] != vt_LONG ||
+		!strings.EqualFold(table.columnNames[0], "modified_tuples") {
+		return 0, nil
+	}
+	return Scalar[int64](table)
+}
+
+// sqlRows adapts the result tables of a response to driver.Rows, one
+// result set per table.
+type sqlRows struct {
+	tables []Table
+	index  int
+	cursor *Table
+}
+
+func (r *sqlRows) Columns() []string {
+	return r.cursor.columnNames
+}
+
+func (r *sqlRows) Close() error {
+	r.tables, r.cursor = nil, new(Table)
+	return nil
+}
+
+func (r *sqlRows) Next(dest []driver.Value) error {
+	if !r.cursor.HasNext() {
+		return io.EOF
+	}
+	values, err := r.cursor.NextValues()
+	if err != nil {
+		return err
+	}
+	for idx, val := range values {
+		dest[idx] = driverValue(val)
+	}
+	return nil
+}
+
+func (r *sqlRows) HasNextResultSet() bool {
+	return r.index+1 < len(r.tables)
+}
+
+func (r *sqlRows) NextResultSet() error {
+	if !r.HasNextResultSet() {
+		return io.EOF
+	}
+	r.index++
+	r.cursor = r.tables[r.index].Cursor()
+	return nil
+}
+
+func (r *sqlRows) ColumnTypeDatabaseTypeName(index int) string {
+	return ColumnType(r.cursor.columnTypes[index]).String()
+}
+
+// ColumnTypeNullable reports every column nullable but not known to
+// be; results do not record whether a column allows NULL.
+func (r *sqlRows) ColumnTypeNullable(index int) (nullable, ok bool) {
+	return true, false
+}
+
+// ColumnTypeScanType returns the type of the values Next returns.
+func (r *sqlRows) ColumnTypeScanType(index int) reflect.Type {
+	switch ColumnType(r.cursor.columnTypes[index]) {
+	case TypeTinyInt, TypeSmallInt, TypeInteger, TypeBigInt:
+		return reflect.TypeOf(int64(0))
+	case TypeDecimal, TypeGeographyPoint, TypeGeography:
+		return reflect.TypeOf("")
+	}
+	return ColumnType(r.cursor.columnTypes[index]).GoType()
+}
+
+func (r *sqlRows) ColumnTypePrecisionScale(index int) (precision, scale int64, ok bool) {
+	if r.cursor.columnTypes[index] != vt_DECIMAL {
+		return 0, 0, false
+	}
+	return decimalPrecision, decimalScale, true
+}
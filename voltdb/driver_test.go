@@ -0,0 +1,223 @@
+package voltdb
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"testing"
+)
+
+// serveTables answers each invocation on conn with tables, after
+// sending the procedure name and first string parameter to calls.
+func serveTables(conn net.Conn, calls chan<- [2]string, tables ...*Table) {
+	if err := serveLogin(conn); err != nil {
+		return
+	}
+	for {
+		msg, err := readTestMessage(conn)
+		if err != nil {
+			return
+		}
+		proc, _ := readString(msg)
+		handle, _ := readLong(msg)
+		var first string
+		if count, _ := readShort(msg); count > 0 {
+			if vt, _ := readByte(msg); vt == vt_STRING {
+				first, _ = readString(msg)
+			}
+		}
+		calls <- [2]string{proc, first}
+
+		var rsp bytes.Buffer
+		writeLong(&rsp, handle)
+		writeByte(&rsp, 0) // fields present
+		writeByte(&rsp, 1) // status
+		writeByte(&rsp, 0) // app status
+		writeInt(&rsp, 0)  // cluster latency
+		writeShort(&rsp, int16(len(tables)))
+		for _, table := range tables {
+			serializeTable(&rsp, table)
+		}
+		if writeTestMessage(conn, rsp) != nil {
+			return
+		}
+	}
+}
+
+func openTestDB(t *testing.T, calls chan<- [2]string, tables ...*Table) *sql.DB {
+	dialer := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		client, server := net.Pipe()
+		go serveTables(server, calls, tables...)
+		return client, nil
+	}
+	db := sql.OpenDB(NewConnector(&Config{Addresses: []string{"pipe:21212"}, Dialer: dialer}))
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestSQLDriverQuery(t *testing.T) {
+	b := NewTableBuilder()
+	b.AddColumn("ID", TypeInteger)
+	b.AddColumn("NAME", TypeString)
+	b.AddColumn("AMOUNT", TypeDecimal)
+	b.AddRow(1, "one", nil)
+	b.AddRow(2, nil, "2.5")
+	calls := make(chan [2]string, 10)
+	db := openTestDB(t, calls, b.Build())
+
+	rows, err := db.Query("select * from items where id > ?;", 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer rows.Close()
+	if call := <-calls; call != [2]string{"@AdHoc", "select * from items where id > ?;"} {
+		t.Errorf("Bad invocation %v", call)
+	}
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if types[0].DatabaseTypeName() != "INTEGER" || types[0].ScanType().Kind().String() != "int64" {
+		t.Errorf("Bad column type %v %v", types[0].DatabaseTypeName(), types[0].ScanType())
+	}
+	if precision, scale, ok := types[2].DecimalSize(); !ok || precision != 38 || scale != 12 {
+		t.Errorf("Bad decimal size %v %v %v", precision, scale, ok)
+	}
+
+	var ids []int
+	var names []sql.NullString
+	var amounts []sql.NullFloat64
+	for rows.Next() {
+		var id int
+		var name sql.NullString
+		var amount sql.NullFloat64
+		if err := rows.Scan(&id, &name, &amount); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		ids, names, amounts = append(ids, id), append(names, name), append(amounts, amount)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(ids) != 2 || ids[1] != 2 || names[0].String != "one" || names[1].Valid ||
+		amounts[0].Valid || amounts[1].Float64 != 2.5 {
+		t.Errorf("Bad rows %v %v %v", ids, names, amounts)
+	}
+}
+
+func TestSQLDriverExec(t *testing.T) {
+	b := NewTableBuilder()
+	b.AddColumn("MODIFIED_TUPLES", TypeBigInt)
+	b.AddRow(3)
+	calls := make(chan [2]string, 10)
+	db := openTestDB(t, calls, b.Build())
+
+	res, err := db.Exec("update items set name = :name where id = :id",
+		sql.Named("name", "x"), sql.Named("id", 1))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if call := <-calls; call != [2]string{"@AdHoc", "update items set name = ? where id = ?"} {
+		t.Errorf("Bad invocation %v", call)
+	}
+	if n, err := res.RowsAffected(); err != nil || n != 3 {
+		t.Errorf("Bad rows affected %v %v", n, err)
+	}
+
+	if _, err := db.Exec("CALL AddItem(?, ?);", "first", 2); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if call := <-calls; call != [2]string{"AddItem", "first"} {
+		t.Errorf("Bad invocation %v", call)
+	}
+	if _, err := db.Exec("CALL AddItem(?)", 1, 2); err == nil {
+		t.Errorf("Expected error for missing placeholder")
+	}
+	if _, err := db.Begin(); err == nil {
+		t.Errorf("Expected error beginning a transaction")
+	}
+}
+
+func TestParseCall(t *testing.T) {
+	tests := []struct {
+		query        string
+		procedure    string
+		placeholders int
+		ok           bool
+	}{
+		{"select * from t", "", 0, false},
+		{"CALL Proc(?, ?)", "Proc", 2, true},
+		{" call Proc() ;", "Proc", 0, true},
+		{"CALL Proc", "Proc", -1, true},
+		{"EXEC Proc ?,?", "Proc", 2, true},
+		{"exec @Statistics", "@Statistics", -1, true},
+	}
+	for _, test := range tests {
+		procedure, placeholders, ok, err := parseCall(test.query)
+		if err != nil || procedure != test.procedure || placeholders != test.placeholders || ok != test.ok {
+			t.Errorf("parseCall(%q) = %v %v %v %v", test.query, procedure, placeholders, ok, err)
+		}
+	}
+	for _, query := range []string{"CALL", "CALL Proc(1, ?)", "EXEC Proc 'a'"} {
+		if _, _, _, err := parseCall(query); err == nil {
+			t.Errorf("Expected error parsing %q", query)
+		}
+	}
+}
+
+func TestSQLDriverRowsAffectedNeedsModifiedTuples(t *testing.T) {
+	b := NewTableBuilder()
+	b.AddColumn("C1", TypeBigInt)
+	b.AddRow(42)
+	db := openTestDB(t, make(chan [2]string, 10), b.Build())
+
+	res, err := db.Exec("SELECT COUNT(*) FROM items;")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n != 0 {
+		t.Errorf("Expected a count not to be rows affected. Have %v %v", n, err)
+	}
+}
+
+func TestSQLDriverPingErrors(t *testing.T) {
+	conn, server := pipeConn(t)
+	c := &sqlConn{conn: conn}
+	go func() {
+		msg, err := readTestMessage(server)
+		if err != nil {
+			return
+		}
+		readString(msg)
+		handle, _ := readLong(msg)
+		var rsp bytes.Buffer
+		writeLong(&rsp, handle)
+		writeByte(&rsp, 1<<5) // fields present: status string
+		writeByte(&rsp, int8(GRACEFUL_FAILURE))
+		writeString(&rsp, "not now")
+		writeByte(&rsp, 0) // app status
+		writeInt(&rsp, 0)  // cluster latency
+		writeShort(&rsp, 0)
+		writeTestMessage(server, rsp)
+	}()
+	if err := c.Ping(context.Background()); err == nil || errors.Is(err, driver.ErrBadConn) {
+		t.Errorf("Expected the failed status as the error. Have %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := c.Ping(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled. Have %v", err)
+	}
+	if !c.IsValid() {
+		t.Errorf("Expected a cancelled ping to keep the connection")
+	}
+
+	conn.Close()
+	if err := c.Ping(context.Background()); !errors.Is(err, driver.ErrBadConn) {
+		t.Errorf("Expected driver.ErrBadConn for a closed Conn. Have %v", err)
+	}
+}
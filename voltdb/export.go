@@ -0,0 +1,230 @@
+package voltdb
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"time"
+)
+
+// export.go encodes tables as JSON, CSV and newline delimited JSON. The
+// writers read one row at a time from a cursor, so neither the rows nor
+// the table's read position are disturbed.
+
+// CSVOptions control how WriteCSV encodes a table.
+type CSVOptions struct {
+	// Comma separates fields, ',' if zero.
+	Comma rune
+	// Null is written for NULL values. VoltDB's csvloader reads \N as NULL.
+	Null string
+	// NoHeader omits the row of column names.
+	NoHeader bool
+}
+
+// MarshalJSON encodes the table as VoltDB's JSON interface does: the
+// status, a schema of column names and types and the rows as arrays.
+func (table *Table) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := table.WriteJSON(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteJSON writes the table to w in the form of MarshalJSON.
+func (table *Table) WriteJSON(w io.Writer) error {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `{"status":%d,"schema":[`, table.statusCode)
+	for idx, name := range table.columnNames {
+		if idx > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(`{"name":`)
+		writeJSONValue(&buf, name)
+		fmt.Fprintf(&buf, `,"type":%d}`, table.columnTypes[idx])
+	}
+	buf.WriteString(`],"data":[`)
+	cursor := table.Cursor()
+	for cursor.HasNext() {
+		values, err := cursor.NextValues()
+		if err != nil {
+			return err
+		}
+		if cursor.Position() > 1 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('[')
+		for idx, val := range values {
+			if idx > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSONValue(&buf, val); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		if err := flushOver(w, &buf); err != nil {
+			return err
+		}
+	}
+	buf.WriteString("]}")
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// WriteNDJSON writes each row of the table to w as a JSON object keyed
+// by column name, one object per line.
+func (table *Table) WriteNDJSON(w io.Writer) error {
+	names := make([][]byte, len(table.columnNames))
+	for idx, name := range table.columnNames {
+		names[idx], _ = json.Marshal(name)
+	}
+	var buf bytes.Buffer
+	cursor := table.Cursor()
+	for cursor.HasNext() {
+		values, err := cursor.NextValues()
+		if err != nil {
+			return err
+		}
+		buf.WriteByte('{')
+		for idx, val := range values {
+			if idx > 0 {
+				buf.WriteByte(',')
+			}
+			buf.Write(names[idx])
+			buf.WriteByte(':')
+			if err := writeJSONValue(&buf, val); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("}\n")
+		if err := flushOver(w, &buf); err != nil {
+			return err
+		}
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// WriteCSV writes the table to w as CSV, after a header of column names
+// unless opts.NoHeader is set.
+func (table *Table) WriteCSV(w io.Writer, opts CSVOptions) error {
+	cw := csv.NewWriter(w)
+	if opts.Comma != 0 {
+		cw.Comma = opts.Comma
+	}
+	if !opts.NoHeader {
+		if err := cw.Write(table.columnNames); err != nil {
+			return err
+		}
+	}
+	record := make([]string, len(table.columnNames))
+	cursor := table.Cursor()
+	for cursor.HasNext() {
+		values, err := cursor.NextValues()
+		if err != nil {
+			return err
+		}
+		for idx, val := range values {
+			if record[idx], err = csvValue(val, opts.Null); err != nil {
+				return err
+			}
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// MarshalJSON encodes the response as VoltDB's JSON interface does, with
+// its statuses and each result table.
+func (rsp *Response) MarshalJSON() ([]byte, error) {
+	results := make([]*Table, len(rsp.tables))
+	for idx := range rsp.tables {
+		results[idx] = &rsp.tables[idx]
+	}
+	return json.Marshal(struct {
+		Status          int      `json:"status"`
+		AppStatus       int      `json:"appstatus"`
+		StatusString    *string  `json:"statusstring"`
+		AppStatusString *string  `json:"appstatusstring"`
+		Results         []*Table `json:"results"`
+	}{
+		Status:          int(rsp.status),
+		AppStatus:       int(rsp.appStatus),
+		StatusString:    optionalString(rsp.statusString),
+		AppStatusString: optionalString(rsp.appStatusString),
+		Results:         results,
+	})
+}
+
+// flushBytes is the size at which the writers pass buffered rows on.
+const flushBytes = 32 * 1024
+
+func flushOver(w io.Writer, buf *bytes.Buffer) error {
+	if buf.Len() < flushBytes {
+		return nil
+	}
+	_, err := w.Write(buf.Bytes())
+	buf.Reset()
+	return err
+}
+
+// writeJSONValue encodes a value returned by readValue. NULL is null,
+// decimals are exact numbers, times are RFC 3339 strings, VARBINARY is
+// base64 and geography is well known text.
+func writeJSONValue(buf *bytes.Buffer, val interface{}) error {
+	switch v := val.(type) {
+	case *big.Rat:
+		val = json.Number(v.FloatString(decimalScale))
+	case GeographyPoint:
+		val = v.String()
+	case Geography:
+		val = v.String()
+	}
+	data, err := json.Marshal(val)
+	if err != nil {
+		return err
+	}
+	buf.Write(data)
+	return nil
+}
+
+// csvValue formats a value returned by readValue as writeJSONValue does,
+// without quotes.
+func csvValue(val interface{}, null string) (string, error) {
+	switch v := val.(type) {
+	case nil:
+		return null, nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case []byte:
+		return base64.StdEncoding.EncodeToString(v), nil
+	case *big.Rat:
+		return v.FloatString(decimalScale), nil
+	case *Table:
+		data, err := v.MarshalJSON()
+		return string(data), err
+	}
+	return fmt.Sprint(val), nil
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,90 @@
+package voltdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func exportTestTable(t *testing.T) *Table {
+	when := time.Date(2020, 1, 2, 3, 4, 5, 6000, time.UTC)
+	return testBuilder(t, []string{"ID", "NAME", "WHEN", "AMOUNT", "BLOB"},
+		[]ColumnType{TypeBigInt, TypeString, TypeTimestamp, TypeDecimal, TypeVarbinary},
+		[]interface{}{1, "one, \"quoted\"", when, big.NewRat(5, 2), []byte{1, 2, 3}},
+		[]interface{}{2, nil, nil, nil, nil}).Build()
+}
+
+func TestTableMarshalJSON(t *testing.T) {
+	table := exportTestTable(t)
+	data, err := json.Marshal(table)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := `{"status":-128,"schema":[{"name":"ID","type":6},{"name":"NAME","type":9},` +
+		`{"name":"WHEN","type":11},{"name":"AMOUNT","type":22},{"name":"BLOB","type":25}],` +
+		`"data":[[1,"one, \"quoted\"","2020-01-02T03:04:05.000006Z",2.500000000000,"AQID"],` +
+		`[2,null,null,null,null]]}`
+	if string(data) != expected {
+		t.Errorf("Bad table JSON. Have\n%s", data)
+	}
+	if table.Position() != 0 {
+		t.Errorf("MarshalJSON moved the read position")
+	}
+
+	rsp := Response{status: int8(SUCCESS), appStatus: -128, tables: []Table{*table}}
+	data, err = json.Marshal(&rsp)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var decoded struct {
+		Status       int
+		StatusString *string
+		Results      []struct {
+			Schema []struct{ Name string }
+			Data   [][]interface{}
+		}
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if decoded.Status != 1 || decoded.StatusString != nil || len(decoded.Results) != 1 ||
+		len(decoded.Results[0].Schema) != 5 || len(decoded.Results[0].Data) != 2 {
+		t.Errorf("Bad response JSON. Have\n%s", data)
+	}
+}
+
+func TestTableWriteNDJSON(t *testing.T) {
+	var out bytes.Buffer
+	if err := exportTestTable(t).WriteNDJSON(&out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := `{"ID":1,"NAME":"one, \"quoted\"","WHEN":"2020-01-02T03:04:05.000006Z",` +
+		`"AMOUNT":2.500000000000,"BLOB":"AQID"}` + "\n" +
+		`{"ID":2,"NAME":null,"WHEN":null,"AMOUNT":null,"BLOB":null}` + "\n"
+	if out.String() != expected {
+		t.Errorf("Bad NDJSON. Have\n%v", out.String())
+	}
+}
+
+func TestTableWriteCSV(t *testing.T) {
+	var out bytes.Buffer
+	if err := exportTestTable(t).WriteCSV(&out, CSVOptions{Null: `\N`}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := "ID,NAME,WHEN,AMOUNT,BLOB\n" +
+		`1,"one, ""quoted""",2020-01-02T03:04:05.000006Z,2.500000000000,AQID` + "\n" +
+		`2,\N,\N,\N,\N` + "\n"
+	if out.String() != expected {
+		t.Errorf("Bad CSV. Have\n%v", out.String())
+	}
+
+	out.Reset()
+	if err := exportTestTable(t).WriteCSV(&out, CSVOptions{Comma: ';', NoHeader: true}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if out.String() != "1;\"one, \"\"quoted\"\"\";2020-01-02T03:04:05.000006Z;2.500000000000;AQID\n2;;;;\n" {
+		t.Errorf("Bad CSV. Have\n%v", out.String())
+	}
+}
@@ -1,9 +1,13 @@
 package voltdb
 
 import (
+	"bytes"
 	"encoding/binary"
+	"fmt"
 	"io"
 	"math"
+	"math/big"
+	"strconv"
 	"time"
 )
 
@@ -14,7 +18,7 @@ import (
 const (
 	vt_ARRAY     int8 = -99 // array (short)(values*)
 	vt_NULL      int8 = 1   // null
-	vt_BOOL      int8 = 3   // boolean, byte
+	vt_TINYINT   int8 = 3   // int8
 	vt_SHORT     int8 = 4   // int16
 	vt_INT       int8 = 5   // int32
 	vt_LONG      int8 = 6   // int64
@@ -23,7 +27,10 @@ const (
 	vt_TIMESTAMP int8 = 11  // int64 timestamp microseconds
 	vt_TABLE     int8 = 21  // VoltTable
 	vt_DECIMAL   int8 = 22  // fix-scaled, fix-precision decimal
+	vt_BOOLEAN   int8 = 23  // boolean, byte
 	vt_VARBIN    int8 = 25  // varbinary (int)(bytes)
+	vt_GEOPOINT  int8 = 26  // geography point (float64 lng)(float64 lat)
+	vt_GEOGRAPHY int8 = 27  // geography polygon (int32-length-prefix)(bytes)
 )
 
 var order = binary.BigEndian
@@ -31,6 +38,15 @@ var order = binary.BigEndian
 // protoVersion is the implemented VoltDB wireprotocol version.
 const protoVersion = 1
 
+// invocation format versions and batch timeout override markers.
+const (
+	invocationVersion0     int8 = 0 // the original format
+	invocationVersion1     int8 = 1 // adds a batch timeout override
+	invocationVersion2     int8 = 2 // adds a request priority
+	noBatchTimeoutOverride int8 = 0
+	batchTimeoutOverride   int8 = 1 // followed by int32 milliseconds
+)
+
 func writeProtoVersion(w io.Writer) error {
 	var b [1]byte
 	b[0] = protoVersion
@@ -38,6 +54,26 @@ func writeProtoVersion(w io.Writer) error {
 	return err
 }
 
+// readN returns the next n bytes of r. Row data is read from a
+// bytes.Buffer; its bytes are returned without a copy.
+func readN(r io.Reader, n int) ([]byte, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("Bad length %d.", n)
+	}
+	if buf, ok := r.(*bytes.Buffer); ok {
+		if buf.Len() == 0 && n > 0 {
+			return nil, io.EOF
+		} else if buf.Len() < n {
+			buf.Next(n)
+			return nil, io.ErrUnexpectedEOF
+		}
+		return buf.Next(n), nil
+	}
+	bs := make([]byte, n)
+	_, err := io.ReadFull(r, bs)
+	return bs, err
+}
+
 func writeBoolean(w io.Writer, d bool) (err error) {
 	if d {
 		err = writeByte(w, 0x1)
@@ -64,13 +100,11 @@ func writeByte(w io.Writer, d int8) error {
 }
 
 func readByte(r io.Reader) (int8, error) {
-	var b [1]byte
-	bs := b[:1]
-	_, err := r.Read(bs)
+	bs, err := readN(r, 1)
 	if err != nil {
 		return 0, err
 	}
-	return int8(b[0]), nil
+	return int8(bs[0]), nil
 }
 
 func readByteArray(r io.Reader) ([]int8, error) {
@@ -99,9 +133,7 @@ func writeShort(w io.Writer, d int16) error {
 }
 
 func readShort(r io.Reader) (int16, error) {
-	var b [2]byte
-	bs := b[:2]
-	_, err := r.Read(bs)
+	bs, err := readN(r, 2)
 	if err != nil {
 		return 0, err
 	}
@@ -118,9 +150,7 @@ func writeInt(w io.Writer, d int32) error {
 }
 
 func readInt(r io.Reader) (int32, error) {
-	var b [4]byte
-	bs := b[:4]
-	_, err := r.Read(bs)
+	bs, err := readN(r, 4)
 	if err != nil {
 		return 0, err
 	}
@@ -137,9 +167,7 @@ func writeLong(w io.Writer, d int64) error {
 }
 
 func readLong(r io.Reader) (int64, error) {
-	var b [8]byte
-	bs := b[:8]
-	_, err := r.Read(bs)
+	bs, err := readN(r, 8)
 	if err != nil {
 		return 0, err
 	}
@@ -147,21 +175,138 @@ func readLong(r io.Reader) (int64, error) {
 	return int64(result), nil
 }
 
+// TIMESTAMPs are microseconds since the epoch, from the start of 1583
+// to the end of 9999.
+const (
+	minTimestamp = -12212553600000000
+	maxTimestamp = 253402300799999999
+)
+
+// readTimestamp reads a timestamp in UTC, or the zero time for NULL.
 func readTimestamp(r io.Reader) (time.Time, error) {
 	us, err := readLong(r)
-	if us != math.MinInt64 {
-		ts := time.Unix(0, us*int64(time.Microsecond))
-		return ts.Round(time.Microsecond), err
+	if err != nil || us == math.MinInt64 {
+		return time.Time{}, err
+	}
+	return time.UnixMicro(us).UTC(), nil
+}
+
+// writeTimestamp writes t, which must be within the TIMESTAMP range.
+// prec decides what happens to a fraction of a microsecond.
+func writeTimestamp(w io.Writer, t time.Time, prec TimestampPrecision) error {
+	us, err := timestampMicros(t, prec)
+	if err != nil {
+		return err
 	}
-	return time.Time{}, err
+	return writeLong(w, us)
 }
 
-func writeTimestamp(w io.Writer, t time.Time) (err error) {
-	nanoSeconds := t.Round(time.Microsecond).UnixNano()
+func timestampMicros(t time.Time, prec TimestampPrecision) (int64, error) {
 	if t.IsZero() {
-		return writeLong(w, math.MinInt64)
+		return 0, fmt.Errorf("The zero time is not a TIMESTAMP; use nil, *time.Time or sql.NullTime for NULL.")
+	}
+	switch prec {
+	case TimestampRound:
+		t = t.Round(time.Microsecond)
+	case TimestampError:
+		if t.Nanosecond()%int(time.Microsecond) != 0 {
+			return 0, fmt.Errorf("Time %v is more precise than a microsecond.", t)
+		}
+	default:
+		t = t.Truncate(time.Microsecond)
+	}
+	us := t.UnixMicro()
+	if us < minTimestamp || us > maxTimestamp {
+		return 0, fmt.Errorf("Time %v is outside the TIMESTAMP range.", t)
+	}
+	return us, nil
+}
+
+// decimals are 16 byte two's complement integers scaled by 10^12, of
+// at most 38 digits.
+const (
+	decimalScale     = 12
+	decimalPrecision = 38
+)
+
+var (
+	decimalDenom = new(big.Int).Exp(big.NewInt(10), big.NewInt(decimalScale), nil)
+	twoTo128     = new(big.Int).Lsh(big.NewInt(1), 128)
+	decimalMax   = new(big.Int).Sub(new(big.Int).Exp(big.NewInt(10), big.NewInt(decimalPrecision), nil), big.NewInt(1))
+)
+
+// readDecimal returns nil for a NULL decimal.
+func readDecimal(r io.Reader) (*big.Rat, error) {
+	bs, err := readN(r, 16)
+	if err != nil {
+		return nil, err
+	}
+	if isNullDecimal(bs) {
+		return nil, nil
+	}
+	unscaled := new(big.Int).SetBytes(bs)
+	if bs[0]&0x80 != 0 {
+		unscaled.Sub(unscaled, twoTo128)
+	}
+	return new(big.Rat).SetFrac(unscaled, decimalDenom), nil
+}
+
+// writeDecimal writes d, or the NULL decimal if d is nil. d must have
+// at most decimalScale digits after the decimal point.
+func writeDecimal(w io.Writer, d *big.Rat) error {
+	bs := make([]byte, 16)
+	if d == nil {
+		bs[0] = 0x80
+		_, err := w.Write(bs)
+		return err
+	}
+	scaled := new(big.Rat).Mul(d, new(big.Rat).SetInt(decimalDenom))
+	if !scaled.IsInt() {
+		return fmt.Errorf("Decimal %v has more than %d digits of scale.", d.RatString(), decimalScale)
+	}
+	unscaled := new(big.Int).Set(scaled.Num())
+	if unscaled.CmpAbs(decimalMax) > 0 {
+		return fmt.Errorf("Decimal %v has more than 38 digits of precision.", d.RatString())
+	}
+	if unscaled.Sign() < 0 {
+		unscaled.Add(unscaled, twoTo128)
+	}
+	_, err := w.Write(unscaled.FillBytes(bs))
+	return err
+}
+
+// floatDecimal returns the decimal of the shortest representation of
+// a float of the given bits, rounded half away from zero to the decimal
+// scale, or nil for infinities and NaN.
+func floatDecimal(f float64, bits int) *big.Rat {
+	d, ok := new(big.Rat).SetString(strconv.FormatFloat(f, 'g', -1, bits))
+	if !ok {
+		return nil
+	}
+	scaled := new(big.Rat).Mul(d, new(big.Rat).SetInt(decimalDenom))
+	if scaled.IsInt() {
+		return d
 	}
-	return writeLong(w, nanoSeconds/int64(time.Microsecond))
+	half := big.NewRat(1, 2)
+	if scaled.Sign() < 0 {
+		half.Neg(half)
+	}
+	scaled.Add(scaled, half)
+	rounded := new(big.Int).Quo(scaled.Num(), scaled.Denom())
+	return new(big.Rat).SetFrac(rounded, decimalDenom)
+}
+
+// the NULL decimal is the smallest 128 bit integer.
+func isNullDecimal(bs []byte) bool {
+	if bs[0] != 0x80 {
+		return false
+	}
+	for _, b := range bs[1:16] {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
 }
 
 func writeFloat(w io.Writer, d float64) error {
@@ -173,9 +318,7 @@ func writeFloat(w io.Writer, d float64) error {
 }
 
 func readFloat(r io.Reader) (float64, error) {
-	var b [8]byte
-	bs := b[:8]
-	_, err := r.Read(bs)
+	bs, err := readN(r, 8)
 	if err != nil {
 		return 0, err
 	}
@@ -199,8 +342,7 @@ func readString(r io.Reader) (result string, err error) {
 		// NULL string not supported, return zero value
 		return
 	}
-	bs := make([]byte, length)
-	_, err = r.Read(bs)
+	bs, err := readN(r, int(length))
 	if err != nil {
 		return
 	}
@@ -230,6 +372,19 @@ func writePasswordBytes(w io.Writer, d []byte) error {
 	return err
 }
 
+// readByteString reads a varbinary value, returning nil for NULL.
+func readByteString(r io.Reader) ([]byte, error) {
+	length, err := readInt(r)
+	if err != nil || length == -1 {
+		return nil, err
+	}
+	bs, err := readN(r, int(length))
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{}, bs...), nil
+}
+
 func writeByteString(w io.Writer, d []byte) error {
 	writeInt(w, int32(len(d)))
 	_, err := w.Write(d)
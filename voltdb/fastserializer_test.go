@@ -2,6 +2,8 @@ package voltdb
 
 import (
 	"bytes"
+	"fmt"
+	"math"
 	"testing"
 	"time"
 )
@@ -133,22 +135,47 @@ func TestRoundTripString(t *testing.T) {
 	}
 }
 
+func TestReadNegativeLength(t *testing.T) {
+	var b bytes.Buffer
+	writeInt(&b, -2)
+	if _, err := readString(bytes.NewBuffer(b.Bytes())); err == nil {
+		t.Errorf("Expected error reading a string of length -2")
+	}
+	if _, err := readByteString(bytes.NewBuffer(b.Bytes())); err == nil {
+		t.Errorf("Expected error reading a byte string of length -2")
+	}
+	if _, err := readString(bytes.NewReader(b.Bytes())); err == nil {
+		t.Errorf("Expected error reading a string of length -2 from a stream")
+	}
+}
+
 func TestRoundTripNullTimestamp(t *testing.T) {
 	var b bytes.Buffer
-	writeTimestamp(&b, time.Time{})
+	if err := writeTimestamp(&b, time.Time{}, TimestampError); err == nil {
+		t.Error("Expected error writing the zero time")
+	}
+	writeLong(&b, nullBigInt)
 	result, _ := readTimestamp(&b)
 	if !result.IsZero() {
 		t.Error("timestamp round trip failed. Want zero-value have non-zero")
 	}
+
+	// the epoch is not NULL.
+	b.Reset()
+	writeTimestamp(&b, time.Unix(0, 0), TimestampError)
+	result, _ = readTimestamp(&b)
+	if result.IsZero() || result.UnixMicro() != 0 {
+		t.Errorf("epoch round trip failed, got %v", result)
+	}
 }
 
 func TestRoundTripNegativeTimestamp(t *testing.T) {
 	ts := time.Unix(-10000, 0)
 
 	var b bytes.Buffer
-	writeTimestamp(&b, ts)
+	writeTimestamp(&b, ts, TimestampError)
 	result, _ := readTimestamp(&b)
-	if result != ts {
+	if !result.Equal(ts) || result.Location() != time.UTC {
 		t.Errorf("timestamp round trip failed, expected %s got %s", ts.String(), result.String())
 	}
 }
@@ -156,9 +183,9 @@ func TestRoundTripNegativeTimestamp(t *testing.T) {
 func TestReflection(t *testing.T) {
 	var b bytes.Buffer
 	var expInt8 int8 = 5
-	marshalParam(&b, expInt8)
+	marshalParam(&b, expInt8, TimestampError)
 	rVtByte, _ := readByte(&b) // volttype
-	if rVtByte != vt_BOOL {
+	if rVtByte != vt_TINYINT {
 		t.Errorf("reflect failed to write volttype byte")
 	}
 	result, _ := readByte(&b)
@@ -168,7 +195,7 @@ func TestReflection(t *testing.T) {
 
 	b.Reset()
 	var expString string = "abcde"
-	marshalParam(&b, expString)
+	marshalParam(&b, expString, TimestampError)
 	rVtString, _ := readByte(&b) // volttype
 	if rVtString != vt_STRING {
 		t.Errorf("reflect failed to write volttype string")
@@ -180,13 +207,81 @@ func TestReflection(t *testing.T) {
 
 	b.Reset()
 	var expTimestamp time.Time = time.Now().Round(time.Microsecond)
-	marshalParam(&b, expTimestamp)
+	marshalParam(&b, expTimestamp, TimestampError)
 	rVtTimestamp, _ := readByte(&b) // volttype
 	if rVtTimestamp != vt_TIMESTAMP {
 		t.Errorf("reflect failed to write volttype timestamp")
 	}
 	rTimestamp, _ := readTimestamp(&b)
-	if rTimestamp != expTimestamp {
+	if !rTimestamp.Equal(expTimestamp) {
 		t.Errorf("timestamp reflection failed. Want %v have %v", expTimestamp, rTimestamp)
 	}
 }
+
+func TestMarshalNumericParams(t *testing.T) {
+	params := []struct {
+		param interface{}
+		vt    int8
+		size  int
+	}{
+		{true, vt_BOOLEAN, 1},
+		{int8(-5), vt_TINYINT, 1},
+		{uint8(200), vt_SHORT, 2},
+		{uint16(60000), vt_INT, 4},
+		{uint32(4000000000), vt_LONG, 8},
+		{uint64(1 << 62), vt_LONG, 8},
+		{float32(1.5), vt_FLOAT, 8},
+	}
+	for _, p := range params {
+		var b bytes.Buffer
+		if err := marshalParam(&b, p.param, TimestampError); err != nil {
+			t.Errorf("Unexpected error marshalling %T: %v", p.param, err)
+			continue
+		}
+		vt, _ := readByte(&b)
+		if vt != p.vt || b.Len() != p.size {
+			t.Errorf("%T marshalled as type %v with %d bytes", p.param, vt, b.Len())
+		}
+		val, err := readValue(&b, vt, nil)
+		if err != nil || fmt.Sprint(val) != fmt.Sprint(p.param) {
+			t.Errorf("%T round trip failed. Have %v: %v", p.param, val, err)
+		}
+	}
+
+	// values that VoltDB can't represent or reserves for NULL.
+	for _, param := range []interface{}{uint64(1 << 63), int8(math.MinInt8),
+		int32(math.MinInt32), math.MinInt64, -math.MaxFloat64} {
+		var b bytes.Buffer
+		if err := marshalParam(&b, param, TimestampError); err == nil {
+			t.Errorf("Expected error marshalling %T %v", param, param)
+		}
+	}
+}
+
+func TestTimestampPrecision(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 123456789, time.UTC)
+	expected := map[TimestampPrecision]int64{
+		TimestampTruncate: ts.UnixMicro(),
+		TimestampRound:    ts.UnixMicro() + 1,
+	}
+	for prec, us := range expected {
+		var b bytes.Buffer
+		if err := writeTimestamp(&b, ts, prec); err != nil {
+			t.Fatalf("Unexpected %v error: %v", prec, err)
+		}
+		if result, _ := readLong(&b); result != us {
+			t.Errorf("Bad %v timestamp. Have %v expected %v", prec, result, us)
+		}
+	}
+
+	var b bytes.Buffer
+	if err := writeTimestamp(&b, ts, TimestampError); err == nil {
+		t.Errorf("Expected error writing a sub-microsecond time")
+	}
+	for _, out := range []time.Time{time.Date(1582, 12, 31, 0, 0, 0, 0, time.UTC),
+		time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC)} {
+		if err := writeTimestamp(&b, out, TimestampTruncate); err == nil {
+			t.Errorf("Expected error writing %v", out)
+		}
+	}
+}
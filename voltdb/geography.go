@@ -0,0 +1,305 @@
+package voltdb
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// geography.go implements VoltDB's GEOGRAPHY_POINT and GEOGRAPHY types
+// and their well known text (WKT) representation.
+
+// A GeographyPoint is a location on the earth in degrees.
+type GeographyPoint struct {
+	Longitude float64
+	Latitude  float64
+}
+
+// A Geography is a polygon on the earth. The first ring is the exterior
+// of the polygon, listed counter-clockwise, and any further rings are
+// holes, listed clockwise. As in WKT, each ring ends with its first
+// point.
+type Geography struct {
+	Rings [][]GeographyPoint
+}
+
+// VoltDB stores a NULL point as out of range coordinates.
+const nullCoord = 360.0
+
+// String returns the point as WKT, e.g. POINT (-71.06 42.36).
+func (p GeographyPoint) String() string {
+	return "POINT (" + formatWKTPoint(p) + ")"
+}
+
+// String returns the polygon as WKT, e.g. POLYGON ((0 0, 1 0, 0 1, 0 0)).
+func (g Geography) String() string {
+	var wkt strings.Builder
+	wkt.WriteString("POLYGON (")
+	for idx, ring := range g.Rings {
+		if idx > 0 {
+			wkt.WriteString(", ")
+		}
+		wkt.WriteString("(")
+		for pidx, p := range ring {
+			if pidx > 0 {
+				wkt.WriteString(", ")
+			}
+			wkt.WriteString(formatWKTPoint(p))
+		}
+		wkt.WriteString(")")
+	}
+	wkt.WriteString(")")
+	return wkt.String()
+}
+
+func formatWKTPoint(p GeographyPoint) string {
+	return strconv.FormatFloat(p.Longitude, 'f', -1, 64) + " " +
+		strconv.FormatFloat(p.Latitude, 'f', -1, 64)
+}
+
+// ParseGeographyPoint parses the WKT of a point, e.g. POINT (-71.06 42.36).
+func ParseGeographyPoint(wkt string) (GeographyPoint, error) {
+	body, ok := wktBody(wkt, "POINT")
+	if !ok {
+		return GeographyPoint{}, fmt.Errorf("Invalid point WKT %q.", wkt)
+	}
+	return parseWKTPoint(body)
+}
+
+// ParseGeography parses the WKT of a polygon, e.g.
+// POLYGON ((0 0, 1 0, 0 1, 0 0)).
+func ParseGeography(wkt string) (Geography, error) {
+	body, ok := wktBody(wkt, "POLYGON")
+	if !ok {
+		return Geography{}, fmt.Errorf("Invalid polygon WKT %q.", wkt)
+	}
+	var g Geography
+	for {
+		body = strings.TrimSpace(body)
+		end := strings.Index(body, ")")
+		if !strings.HasPrefix(body, "(") || end < 0 {
+			return Geography{}, fmt.Errorf("Invalid polygon WKT %q.", wkt)
+		}
+		var ring []GeographyPoint
+		for _, coords := range strings.Split(body[1:end], ",") {
+			p, err := parseWKTPoint(coords)
+			if err != nil {
+				return Geography{}, err
+			}
+			ring = append(ring, p)
+		}
+		g.Rings = append(g.Rings, ring)
+
+		body = strings.TrimSpace(body[end+1:])
+		if body == "" {
+			break
+		}
+		if body, ok = strings.CutPrefix(body, ","); !ok {
+			return Geography{}, fmt.Errorf("Invalid polygon WKT %q.", wkt)
+		}
+	}
+	return g, g.validate()
+}
+
+// wktBody returns the text between the parentheses following tag.
+func wktBody(wkt string, tag string) (string, bool) {
+	s := strings.TrimSpace(wkt)
+	if len(s) < len(tag) || !strings.EqualFold(s[:len(tag)], tag) {
+		return "", false
+	}
+	s = strings.TrimSpace(s[len(tag):])
+	if !strings.HasPrefix(s, "(") || !strings.HasSuffix(s, ")") {
+		return "", false
+	}
+	return s[1 : len(s)-1], true
+}
+
+func parseWKTPoint(s string) (GeographyPoint, error) {
+	coords := strings.Fields(s)
+	if len(coords) != 2 {
+		return GeographyPoint{}, fmt.Errorf("Invalid WKT coordinates %q.", s)
+	}
+	lng, err := strconv.ParseFloat(coords[0], 64)
+	if err != nil {
+		return GeographyPoint{}, fmt.Errorf("Invalid WKT longitude %q.", coords[0])
+	}
+	lat, err := strconv.ParseFloat(coords[1], 64)
+	if err != nil {
+		return GeographyPoint{}, fmt.Errorf("Invalid WKT latitude %q.", coords[1])
+	}
+	p := GeographyPoint{lng, lat}
+	return p, p.validate()
+}
+
+func (p GeographyPoint) validate() error {
+	if math.Abs(p.Longitude) > 180 || math.Abs(p.Latitude) > 90 {
+		return fmt.Errorf("Point %v is out of range.", p)
+	}
+	return nil
+}
+
+func (g Geography) validate() error {
+	if len(g.Rings) == 0 {
+		return fmt.Errorf("Polygon has no rings.")
+	}
+	for _, ring := range g.Rings {
+		if len(ring) < 4 || ring[0] != ring[len(ring)-1] {
+			return fmt.Errorf("Polygon ring must have at least 4 points and end with its first point.")
+		}
+		for _, p := range ring {
+			if err := p.validate(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func writeGeographyPoint(w io.Writer, p GeographyPoint) error {
+	if err := writeFloat(w, p.Longitude); err != nil {
+		return err
+	}
+	return writeFloat(w, p.Latitude)
+}
+
+// readGeographyPoint reads a point and reports whether it is NULL.
+func readGeographyPoint(r io.Reader) (GeographyPoint, bool, error) {
+	lng, err := readFloat(r)
+	if err != nil {
+		return GeographyPoint{}, false, err
+	}
+	lat, err := readFloat(r)
+	if err != nil {
+		return GeographyPoint{}, false, err
+	}
+	if lng == nullCoord && lat == nullCoord {
+		return GeographyPoint{}, true, nil
+	}
+	return GeographyPoint{lng, lat}, false, nil
+}
+
+// Polygons are serialized as VoltDB's GeographyValue, which is the
+// layout of an S2Polygon: loops of unit vectors, without the closing
+// point of WKT and with every loop counter-clockwise.
+const (
+	geoEncodingVersion = 0
+	geoOwnsLoops       = 1
+)
+
+// writeGeography writes g, without its length prefix.
+func writeGeography(w io.Writer, g Geography) error {
+	if err := g.validate(); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	writeByte(&buf, geoEncodingVersion)
+	writeByte(&buf, geoOwnsLoops)
+	if len(g.Rings) > 1 {
+		writeByte(&buf, 1)
+	} else {
+		writeByte(&buf, 0)
+	}
+	writeInt(&buf, int32(len(g.Rings)))
+	for idx, ring := range g.Rings {
+		loop := ring[:len(ring)-1]
+		writeByte(&buf, geoEncodingVersion)
+		writeInt(&buf, int32(len(loop)))
+		for pidx := range loop {
+			p := loop[pidx]
+			if idx > 0 {
+				// holes are clockwise in WKT.
+				p = loop[len(loop)-1-pidx]
+			}
+			x, y, z := p.xyz()
+			writeFloat(&buf, x)
+			writeFloat(&buf, y)
+			writeFloat(&buf, z)
+		}
+		writeByte(&buf, 0)                 // origin inside
+		writeInt(&buf, int32(min(idx, 1))) // depth
+		writeEmptyBound(&buf)
+	}
+	writeEmptyBound(&buf)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// writeEmptyBound writes a latitude-longitude bounding rectangle that
+// the server recomputes.
+func writeEmptyBound(w io.Writer) {
+	writeByte(w, geoEncodingVersion)
+	for i := 0; i < 4; i++ {
+		writeFloat(w, 0)
+	}
+}
+
+// readGeography reads a polygon from raw, its bytes without the
+// length prefix.
+func readGeography(raw []byte) (Geography, error) {
+	r := bytes.NewBuffer(raw)
+	if _, err := readN(r, 3); err != nil {
+		return Geography{}, err
+	}
+	loops, err := readInt(r)
+	if err != nil {
+		return Geography{}, err
+	}
+	if loops < 0 || int(loops) > r.Len() {
+		return Geography{}, fmt.Errorf("Invalid polygon with %d loops.", loops)
+	}
+	g := Geography{Rings: make([][]GeographyPoint, loops)}
+	for idx := range g.Rings {
+		if _, err = readByte(r); err != nil {
+			return Geography{}, err
+		}
+		count, err := readInt(r)
+		if err != nil {
+			return Geography{}, err
+		}
+		if count < 0 || int(count) > r.Len()/24 {
+			return Geography{}, fmt.Errorf("Invalid polygon loop with %d points.", count)
+		}
+		ring := make([]GeographyPoint, count, count+1)
+		for pidx := range ring {
+			var xyz [3]float64
+			for c := range xyz {
+				if xyz[c], err = readFloat(r); err != nil {
+					return Geography{}, err
+				}
+			}
+			if idx > 0 {
+				ring[len(ring)-1-pidx] = pointFromXYZ(xyz[0], xyz[1], xyz[2])
+			} else {
+				ring[pidx] = pointFromXYZ(xyz[0], xyz[1], xyz[2])
+			}
+		}
+		if len(ring) > 0 {
+			ring = append(ring, ring[0])
+		}
+		g.Rings[idx] = ring
+		// origin inside, depth and bound.
+		if _, err = readN(r, 1+4+1+32); err != nil {
+			return Geography{}, err
+		}
+	}
+	return g, nil
+}
+
+// xyz returns p as a unit vector.
+func (p GeographyPoint) xyz() (x, y, z float64) {
+	lat := p.Latitude * math.Pi / 180
+	lng := p.Longitude * math.Pi / 180
+	return math.Cos(lat) * math.Cos(lng), math.Cos(lat) * math.Sin(lng), math.Sin(lat)
+}
+
+// pointFromXYZ converts a unit vector to a point, rounded to 12 decimal
+// places to hide the error of the conversion.
+func pointFromXYZ(x, y, z float64) GeographyPoint {
+	round := func(deg float64) float64 { return math.Round(deg*1e12) / 1e12 }
+	lat := math.Atan2(z, math.Hypot(x, y)) * 180 / math.Pi
+	lng := math.Atan2(y, x) * 180 / math.Pi
+	return GeographyPoint{round(lng), round(lat)}
+}
@@ -0,0 +1,83 @@
+package voltdb
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestGeographyWKT(t *testing.T) {
+	p, err := ParseGeographyPoint(" point(-71.06 42.36) ")
+	if err != nil || p != (GeographyPoint{-71.06, 42.36}) || p.String() != "POINT (-71.06 42.36)" {
+		t.Errorf("Bad point %v: %v", p, err)
+	}
+
+	wkt := "POLYGON ((0 0, 10 0, 10 10, 0 10, 0 0), (2 2, 2 3, 3 3, 3 2, 2 2))"
+	g, err := ParseGeography(wkt)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(g.Rings) != 2 || len(g.Rings[1]) != 5 || g.String() != wkt {
+		t.Errorf("Bad polygon %v", g)
+	}
+
+	for _, bad := range []string{"POINT (1)", "POINT (200 0)", "LINESTRING (0 0, 1 1)"} {
+		if _, err := ParseGeographyPoint(bad); err == nil {
+			t.Errorf("Expected error parsing %v", bad)
+		}
+	}
+	for _, bad := range []string{"POLYGON ((0 0, 1 0, 0 0))", "POLYGON ((0 0, 1 0, 1 1, 0 1))",
+		"POLYGON ((0 0, 1 0, 1 1, 0 0) (0 0, 1 0, 1 1, 0 0))", "POLYGON ()"} {
+		if _, err := ParseGeography(bad); err == nil {
+			t.Errorf("Expected error parsing %v", bad)
+		}
+	}
+}
+
+func TestGeographyRoundTrip(t *testing.T) {
+	point := GeographyPoint{-71.0589, 42.3601}
+	polygon, _ := ParseGeography("POLYGON ((-1 -1, 1 -1, 1 1, -1 1, -1 -1), (-0.5 -0.5, -0.5 0.5, 0.5 0.5, 0.5 -0.5, -0.5 -0.5))")
+
+	b := NewTableBuilder()
+	b.AddColumn("POINT", TypeGeographyPoint)
+	b.AddColumn("AREA", TypeGeography)
+	b.AddColumn("WKT", TypeGeography)
+	if err := b.AddRow(point, polygon, polygon.String()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := b.AddRow(nil, nil, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	table := b.Build()
+
+	var row struct {
+		Point GeographyPoint
+		Area  *Geography
+		WKT   string
+	}
+	if err := table.Next(&row); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if row.Point != point || row.Area == nil || row.Area.String() != polygon.String() || row.WKT != polygon.String() {
+		t.Errorf("Bad row %v %v %v", row.Point, row.Area, row.WKT)
+	}
+	if err := table.Next(&row); err != nil || row.Point != (GeographyPoint{}) || row.Area != nil || row.WKT != "" {
+		t.Errorf("Bad NULL row %v %v %q: %v", row.Point, row.Area, row.WKT, err)
+	}
+
+	for _, param := range []interface{}{point, polygon} {
+		var buf bytes.Buffer
+		if err := marshalParam(&buf, param, TimestampError); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		vt, _ := readByte(&buf)
+		val, err := readValue(&buf, vt, nil)
+		if err != nil || val.(fmt.Stringer).String() != param.(fmt.Stringer).String() {
+			t.Errorf("Bad parameter round trip of %v: %v %v", param, val, err)
+		}
+	}
+	var buf bytes.Buffer
+	if err := marshalParam(&buf, GeographyPoint{0, 91}, TimestampError); err == nil {
+		t.Errorf("Expected error marshalling out of range point")
+	}
+}
@@ -0,0 +1,61 @@
+package voltdb
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// ErrConnectionDead is returned, wrapped, to calls pending on a
+// connection that stopped answering heartbeats.
+var ErrConnectionDead = errors.New("Connection is not responding")
+
+// heartbeat pings netConn whenever it has been idle for the configured
+// interval. A ping unanswered within the heartbeat timeout marks the
+// connection dead, which fails its pending calls and, if enabled,
+// starts reconnecting.
+func (conn *Conn) heartbeat(netConn net.Conn) {
+	interval := conn.cfg.HeartbeatInterval
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if !conn.isCurrent(netConn) {
+			return
+		}
+		idle := time.Since(time.Unix(0, conn.lastRead.Load()))
+		if idle < interval {
+			continue
+		}
+		_, used, err := conn.invoke("@Ping", nil, callOptions{}, conn.cfg.HeartbeatTimeout)
+		if used != netConn {
+			return
+		}
+		if errors.Is(err, ErrTimeout) {
+			conn.connectionLost(netConn, fmt.Errorf("%w: no heartbeat response in %v",
+				ErrConnectionDead, idle+conn.cfg.HeartbeatTimeout))
+			if conn.cfg.Reconnect.MaxAttempts > 0 {
+				go conn.reconnectIfLost()
+			}
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// isCurrent reports whether netConn is still the connection in use.
+func (conn *Conn) isCurrent(netConn net.Conn) bool {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	return conn.netConn == netConn
+}
+
+// reconnectIfLost re-establishes a lost, unclosed connection.
+func (conn *Conn) reconnectIfLost() {
+	if err := conn.reconnect(); err != nil && !conn.isClosed() {
+		conn.logf("reconnect failed: %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+package voltdb
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestHeartbeatFailsPendingCalls(t *testing.T) {
+	conn, server := pipeConn(t, WithHeartbeat(10*time.Millisecond, 10*time.Millisecond))
+	defer conn.Close()
+	defer server.Close()
+
+	// the server reads requests but never answers, like a half-open socket.
+	go func() {
+		for {
+			if _, err := readTestMessage(server); err != nil {
+				return
+			}
+		}
+	}()
+	_, err := conn.Call("Slow")
+	if !errors.Is(err, ErrConnectionDead) {
+		t.Fatalf("Expected dead connection error. Have %v", err)
+	}
+}
+
+func TestHeartbeatKeepsAnsweredConnection(t *testing.T) {
+	conn, server := pipeConn(t, WithHeartbeat(5*time.Millisecond, 50*time.Millisecond))
+	defer conn.Close()
+	go func() {
+		for {
+			if _, err := serveCall(server); err != nil {
+				return
+			}
+		}
+	}()
+	time.Sleep(30 * time.Millisecond)
+	if !conn.TestConnection() {
+		t.Errorf("Expected answered heartbeats to keep the connection alive")
+	}
+}
+
+func TestHeartbeatReconnects(t *testing.T) {
+	dialed := make(chan net.Conn, 2)
+	dialer := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		client, server := net.Pipe()
+		go serveLogin(server)
+		dialed <- server
+		return client, nil
+	}
+	conn, err := DialWithOptions(WithAddresses("pipe:21212"), WithDialer(dialer),
+		WithHeartbeat(10*time.Millisecond, 10*time.Millisecond),
+		WithReconnect(1, 0))
+	if err != nil {
+		t.Fatalf("Unexpected connection error: %v", err)
+	}
+	defer conn.Close()
+
+	first := <-dialed
+	go func() {
+		for {
+			if _, err := readTestMessage(first); err != nil {
+				return
+			}
+		}
+	}()
+	select {
+	case second := <-dialed:
+		second.Close()
+	case <-time.After(time.Second):
+		t.Fatalf("Expected a dead connection to be replaced")
+	}
+}
+
+func TestHeartbeatReconnectDoesNotBlockCallers(t *testing.T) {
+	dialing := make(chan struct{}, 1)
+	release := make(chan struct{})
+	first := true
+	dialer := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if !first {
+			dialing <- struct{}{}
+			<-release
+			return nil, errors.New("refused")
+		}
+		first = false
+		client, server := net.Pipe()
+		go func() {
+			serveLogin(server)
+			for {
+				if _, err := readTestMessage(server); err != nil {
+					return
+				}
+			}
+		}()
+		return client, nil
+	}
+	conn, err := DialWithOptions(WithAddresses("pipe:21212"), WithDialer(dialer),
+		WithHeartbeat(10*time.Millisecond, 10*time.Millisecond),
+		WithReconnect(1, 0))
+	if err != nil {
+		t.Fatalf("Unexpected connection error: %v", err)
+	}
+	defer close(release)
+
+	select {
+	case <-dialing:
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the heartbeat to reconnect")
+	}
+	done := make(chan struct{})
+	go func() {
+		conn.GoString()
+		conn.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Close blocked on the heartbeat's reconnect")
+	}
+}
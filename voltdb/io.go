@@ -3,9 +3,13 @@ package voltdb
 import (
 	"bytes"
 	"crypto/sha1"
+	"crypto/sha256"
 	"errors"
 	"fmt"
+	"hash"
 	"io"
+	"math"
+	"net"
 	"reflect"
 	"runtime"
 	"time"
@@ -14,42 +18,45 @@ import (
 // io.go includes protocol-level de/serialization code. For
 // example, serialize and write a procedure call to the network.
 
-// writeMessage prepends a header and writes header and buf to tcpConn
-// Table represents a VoltDB table, often as a procedure result set.
-// Wrap up some metdata with pointer(s) to row data. Tables are
-// relatively cheap to copy (the associated user data is copied
-// reference).
-func (conn *Conn) writeMessage(buf bytes.Buffer) error {
-	// length includes protocol version.
+// writeMessage prepends a header of the length and version byte and
+// writes header and buf to netConn.
+func (conn *Conn) writeMessage(netConn net.Conn, version int8, buf bytes.Buffer) error {
+	// length includes the version.
 	length := buf.Len() + 1
 	var netmsg bytes.Buffer
 	writeInt(&netmsg, int32(length))
-	writeProtoVersion(&netmsg)
+	writeByte(&netmsg, version)
 	// 1 copy + 1 n/w write benchmarks faster than 2 n/w writes.
 	io.Copy(&netmsg, &buf)
-	io.Copy(conn.tcpConn, &netmsg)
-	// TODO: obviously wrong
-	return nil
+	if conn.cfg.WriteTimeout > 0 {
+		netConn.SetWriteDeadline(time.Now().Add(conn.cfg.WriteTimeout))
+	}
+	_, err := io.Copy(netConn, &netmsg)
+	return err
 }
 
 // readMessageHdr reads the standard wireprotocol header.
-func (conn *Conn) readMessageHdr() (size int32, err error) {
+func (conn *Conn) readMessageHdr(r io.Reader) (size int32, err error) {
 	// Total message length Integer  4
-	size, err = readInt(conn.tcpConn)
+	size, err = readInt(r)
 	if err != nil {
 		return
 	}
+	if size < 1 || size > conn.cfg.MaxMessageSize {
+		return 0, fmt.Errorf("Invalid message length %d.", size)
+	}
 	return (size), nil
 }
 
-// readLoginResponse parses the login response message.
-func (conn *Conn) readMessage() (*bytes.Buffer, error) {
-	size, err := conn.readMessageHdr()
+// readMessage reads one message, returning the bytes following the
+// header.
+func (conn *Conn) readMessage(r io.Reader) (*bytes.Buffer, error) {
+	size, err := conn.readMessageHdr(r)
 	if err != nil {
 		return nil, err
 	}
 	data := make([]byte, size)
-	if _, err = io.ReadFull(conn.tcpConn, data); err != nil {
+	if _, err = io.ReadFull(r, data); err != nil {
 		return nil, err
 	}
 	buf := bytes.NewBuffer(data)
@@ -63,12 +70,41 @@ func (conn *Conn) readMessage() (*bytes.Buffer, error) {
 	return buf, nil
 }
 
-func serializeLoginMessage(user string, passwd string) (msg bytes.Buffer, err error) {
-	h := sha1.New()
+// readLoop reads responses from netConn and hands each to the call
+// waiting on its client handle, until netConn fails or is closed.
+func (conn *Conn) readLoop(netConn net.Conn) {
+	for {
+		buf, err := conn.readMessage(netConn)
+		if err != nil {
+			conn.connectionLost(netConn, err)
+			return
+		}
+		conn.lastRead.Store(time.Now().UnixNano())
+		// every response begins with the client handle.
+		if buf.Len() < 8 {
+			conn.connectionLost(netConn, fmt.Errorf("Response too short."))
+			return
+		}
+		conn.dispatch(int64(order.Uint64(buf.Bytes())), buf)
+	}
+}
+
+func serializeLoginMessage(user string, passwd string, service string,
+	scheme HashScheme) (msg bytes.Buffer, err error) {
+	var h hash.Hash
+	if scheme == HashSHA256 {
+		// the hash scheme is only sent when not the original SHA-1.
+		h = sha256.New()
+		if err = writeByte(&msg, int8(scheme)); err != nil {
+			return
+		}
+	} else {
+		h = sha1.New()
+	}
 	io.WriteString(h, passwd)
 	shabytes := h.Sum(nil)
 
-	err = writeString(&msg, "database")
+	err = writeString(&msg, service)
 	if err != nil {
 		return
 	}
@@ -83,8 +119,8 @@ func serializeLoginMessage(user string, passwd string) (msg bytes.Buffer, err er
 	return
 }
 
-func (conn *Conn) readLoginResponse() (*connectionData, error) {
-	buf, err := conn.readMessage()
+func (conn *Conn) readLoginResponse(netConn net.Conn) (*connectionData, error) {
+	buf, err := conn.readMessage(netConn)
 	if err != nil {
 		return nil, err
 	}
@@ -141,7 +177,8 @@ func deserializeLoginResponse(r io.Reader) (connData *connectionData, err error)
 	return connData, nil
 }
 
-func serializeCall(proc string, ud int64, params []interface{}) (msg bytes.Buffer, err error) {
+func serializeCall(proc string, ud int64, params []interface{},
+	opts callOptions) (msg bytes.Buffer, err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			if _, ok := r.(runtime.Error); ok {
@@ -151,6 +188,9 @@ func serializeCall(proc string, ud int64, params []interface{}) (msg bytes.Buffe
 		}
 	}()
 
+	if err = writeInvocationHeader(&msg, opts); err != nil {
+		return
+	}
 	if err = writeString(&msg, proc); err != nil {
 		return
 	}
@@ -158,7 +198,7 @@ func serializeCall(proc string, ud int64, params []interface{}) (msg bytes.Buffe
 		return
 	}
 
-	serializedParams, err := serializeParams(params)
+	serializedParams, err := serializeParams(params, opts.precision)
 	if err != nil {
 		return
 	}
@@ -166,63 +206,174 @@ func serializeCall(proc string, ud int64, params []interface{}) (msg bytes.Buffe
 	return
 }
 
-func serializeParams(params []interface{}) (msg bytes.Buffer, err error) {
+// invocationVersion returns the version byte of the message invoking a
+// call with opts: the original format without a timeout or priority,
+// version 1 for a batch timeout and version 2 for a priority.
+func invocationVersion(opts callOptions) int8 {
+	switch {
+	case opts.priority != 0:
+		return invocationVersion2
+	case opts.timeout != 0:
+		return invocationVersion1
+	}
+	return invocationVersion0
+}
+
+// writeInvocationHeader writes the fields that follow the version byte
+// and precede the procedure name: none in the original format, else
+// the batch timeout override and, from version 2, the request priority.
+func writeInvocationHeader(w io.Writer, opts callOptions) (err error) {
+	version := invocationVersion(opts)
+	if version == invocationVersion0 {
+		return nil
+	}
+	if opts.timeout > 0 {
+		millis := opts.timeout.Milliseconds()
+		if millis < 1 {
+			millis = 1
+		} else if millis > math.MaxInt32 {
+			millis = math.MaxInt32
+		}
+		if err = writeByte(w, batchTimeoutOverride); err != nil {
+			return
+		}
+		err = writeInt(w, int32(millis))
+	} else {
+		err = writeByte(w, noBatchTimeoutOverride)
+	}
+	if err == nil && version == invocationVersion2 {
+		err = writeByte(w, int8(opts.priority))
+	}
+	return
+}
+
+func serializeParams(params []interface{}, prec TimestampPrecision) (msg bytes.Buffer, err error) {
 	// parameter_count short
 	// (type byte, parameter)*
 	if err = writeShort(&msg, int16(len(params))); err != nil {
 		return
 	}
 	for _, val := range params {
-		if err = marshalParam(&msg, val); err != nil {
+		if err = marshalParam(&msg, val, prec); err != nil {
 			return
 		}
 	}
 	return
 }
 
-func marshalParam(buf io.Writer, param interface{}) (err error) {
+// paramRangeError describes a numeric parameter that VoltDB can not
+// represent, including values VoltDB reserves for NULL.
+func paramRangeError(param interface{}, vt int8) error {
+	return fmt.Errorf("Parameter %v is out of range for %v.", param, ColumnType(vt))
+}
+
+func marshalParam(buf io.Writer, param interface{}, prec TimestampPrecision) (err error) {
+	if ok, err := marshalCustomParam(buf, param, prec); ok {
+		return err
+	}
+	if t, ok := param.(*Table); ok {
+		if t == nil {
+			return writeByte(buf, vt_NULL)
+		}
+		writeByte(buf, vt_TABLE)
+		return serializeTable(buf, t)
+	}
 	v := reflect.ValueOf(param)
 	if !v.IsValid() {
-		return errors.New("Can not encode value.")
+		// untyped nil is SQL NULL.
+		return writeByte(buf, vt_NULL)
 	}
 	switch v.Kind() {
 	case reflect.Bool:
 		x := v.Bool()
-		writeByte(buf, vt_BOOL)
+		writeByte(buf, vt_BOOLEAN)
 		err = writeBoolean(buf, x)
 	case reflect.Int8:
 		x := v.Int()
-		writeByte(buf, vt_BOOL)
+		if x == nullTinyInt {
+			return paramRangeError(param, vt_TINYINT)
+		}
+		writeByte(buf, vt_TINYINT)
 		err = writeByte(buf, int8(x))
 	case reflect.Int16:
 		x := v.Int()
+		if x == nullSmallInt {
+			return paramRangeError(param, vt_SHORT)
+		}
 		writeByte(buf, vt_SHORT)
 		err = writeShort(buf, int16(x))
 	case reflect.Int32:
 		x := v.Int()
+		if x == nullInteger {
+			return paramRangeError(param, vt_INT)
+		}
 		writeByte(buf, vt_INT)
 		err = writeInt(buf, int32(x))
 	case reflect.Int, reflect.Int64:
 		x := v.Int()
+		if x == nullBigInt {
+			return paramRangeError(param, vt_LONG)
+		}
 		writeByte(buf, vt_LONG)
 		err = writeLong(buf, int64(x))
-	case reflect.Float64:
+	// unsigned integers are sent as the next wider signed type.
+	case reflect.Uint8:
+		writeByte(buf, vt_SHORT)
+		err = writeShort(buf, int16(v.Uint()))
+	case reflect.Uint16:
+		writeByte(buf, vt_INT)
+		err = writeInt(buf, int32(v.Uint()))
+	case reflect.Uint32, reflect.Uint, reflect.Uint64, reflect.Uintptr:
+		x := v.Uint()
+		if x > math.MaxInt64 {
+			return paramRangeError(param, vt_LONG)
+		}
+		writeByte(buf, vt_LONG)
+		err = writeLong(buf, int64(x))
+	case reflect.Float32, reflect.Float64:
 		x := v.Float()
+		if x <= nullFloat {
+			return paramRangeError(param, vt_FLOAT)
+		}
 		writeByte(buf, vt_FLOAT)
-		err = writeFloat(buf, float64(x))
+		err = writeFloat(buf, x)
 	case reflect.String:
 		x := v.String()
 		writeByte(buf, vt_STRING)
 		err = writeString(buf, x)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("Can't marshal %v-type parameters", v.Type())
+		}
+		writeByte(buf, vt_VARBIN)
+		err = writeByteString(buf, v.Bytes())
 	case reflect.Struct:
-		if t, ok := v.Interface().(time.Time); ok {
+		switch x := v.Interface().(type) {
+		case time.Time:
+			var us int64
+			if us, err = timestampMicros(x, prec); err != nil {
+				return err
+			}
 			writeByte(buf, vt_TIMESTAMP)
-			writeTimestamp(buf, t)
-		} else {
-			panic("Can't marshal struct-type parameters")
+			err = writeLong(buf, us)
+		case GeographyPoint:
+			if err = x.validate(); err != nil {
+				return err
+			}
+			writeByte(buf, vt_GEOPOINT)
+			err = writeGeographyPoint(buf, x)
+		case Geography:
+			var raw bytes.Buffer
+			if err = writeGeography(&raw, x); err != nil {
+				return err
+			}
+			writeByte(buf, vt_GEOGRAPHY)
+			err = writeByteString(buf, raw.Bytes())
+		default:
+			return fmt.Errorf("Can't marshal %v parameters; use CallStruct to expand structs.", v.Type())
 		}
 	default:
-		panic(fmt.Sprintf("Can't marshal %v-type parameters", v.Kind()))
+		return fmt.Errorf("Can't marshal %v-type parameters", v.Kind())
 	}
 	return
 }
@@ -344,6 +495,39 @@ func deserializeTable(r io.Reader) (t Table, err error) {
 	// if that way lies madness or cleverness. For now, suck
 	// up the copy. Maybe in the future change this method
 	// to take a buffer instead of a reader?
-	io.CopyN(&t.rows, r, tableByteCount)
+	if tableByteCount < 0 {
+		return errTable, fmt.Errorf("Invalid table length %d.", ttlLength)
+	}
+	t.data = make([]byte, tableByteCount)
+	if _, err = io.ReadFull(r, t.data); err != nil {
+		return errTable, err
+	}
+	t.index = new(rowIndex)
+	t.Reset()
 	return t, nil
 }
+
+// serializeTable writes t in the format read by deserializeTable. All
+// of the table's rows are written, whatever its read position.
+func serializeTable(w io.Writer, t *Table) error {
+	// status, column count, column types and length prefixed names.
+	metaLength := 1 + 2 + len(t.columnTypes)
+	for _, name := range t.columnNames {
+		metaLength += 4 + len(name)
+	}
+	var buf bytes.Buffer
+	writeInt(&buf, int32(4+metaLength+4+len(t.data)))
+	writeInt(&buf, int32(metaLength))
+	writeByte(&buf, t.statusCode)
+	writeShort(&buf, int16(len(t.columnTypes)))
+	for _, ct := range t.columnTypes {
+		writeByte(&buf, ct)
+	}
+	for _, name := range t.columnNames {
+		writeString(&buf, name)
+	}
+	writeInt(&buf, t.rowCount)
+	buf.Write(t.data)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
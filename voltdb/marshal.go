@@ -0,0 +1,127 @@
+package voltdb
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"math/big"
+	"reflect"
+	"time"
+)
+
+// marshal.go lets application types take part in encoding parameters
+// and decoding columns, either through the VoltDB specific interfaces
+// below or through database/sql's driver.Valuer and sql.Scanner.
+
+// VoltUnmarshaler is implemented by types that decode themselves from
+// a column value. colType is the column's type and raw its wire
+// bytes, without the length prefix of variable length types, or nil
+// for NULL. raw must be copied if it is retained.
+type VoltUnmarshaler interface {
+	UnmarshalVolt(colType ColumnType, raw []byte) error
+}
+
+// VoltMarshaler is implemented by types that encode themselves as a
+// procedure parameter. raw is the parameter's wire bytes, without the
+// length prefix of variable length types; nil raw sends NULL.
+type VoltMarshaler interface {
+	MarshalVolt() (colType ColumnType, raw []byte, err error)
+}
+
+var (
+	unmarshalerType = reflect.TypeOf((*VoltUnmarshaler)(nil)).Elem()
+	scannerType     = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	marshalerType   = reflect.TypeOf((*VoltMarshaler)(nil)).Elem()
+	valuerType      = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+)
+
+// unmarshalerDecoder returns a decodeFunc for fields whose address
+// implements VoltUnmarshaler or sql.Scanner, or nil for other fields.
+func unmarshalerDecoder(vt int8, t reflect.Type, loc *time.Location) decodeFunc {
+	ptr := reflect.PointerTo(t)
+	switch {
+	case ptr.Implements(unmarshalerType):
+		return func(r *bytes.Buffer, f reflect.Value) error {
+			raw, err := readRaw(r, vt)
+			if err != nil {
+				return err
+			}
+			return f.Addr().Interface().(VoltUnmarshaler).UnmarshalVolt(ColumnType(vt), raw)
+		}
+	case ptr.Implements(scannerType):
+		return func(r *bytes.Buffer, f reflect.Value) error {
+			val, err := readValue(r, vt, loc)
+			if err != nil {
+				return err
+			}
+			return f.Addr().Interface().(sql.Scanner).Scan(driverValue(val))
+		}
+	}
+	return nil
+}
+
+// driverValue converts a value returned by readValue to one of the
+// types database/sql passes to a Scanner.
+func driverValue(val interface{}) driver.Value {
+	switch v := val.(type) {
+	case int8:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case *big.Rat:
+		return v.FloatString(decimalScale)
+	case GeographyPoint:
+		return v.String()
+	case Geography:
+		return v.String()
+	}
+	return val
+}
+
+// marshalCustomParam encodes params implementing VoltMarshaler or
+// driver.Valuer. It reports false for other params.
+func marshalCustomParam(buf io.Writer, param interface{}, prec TimestampPrecision) (bool, error) {
+	if nilValueReceiver(param, marshalerType) || nilValueReceiver(param, valuerType) {
+		return true, writeByte(buf, vt_NULL)
+	}
+	switch p := param.(type) {
+	case VoltMarshaler:
+		vt, raw, err := p.MarshalVolt()
+		if err != nil {
+			return true, err
+		}
+		if raw == nil {
+			return true, writeByte(buf, vt_NULL)
+		}
+		if err = writeByte(buf, int8(vt)); err != nil {
+			return true, err
+		}
+		if vt == TypeString || vt == TypeVarbinary || vt == TypeGeography {
+			return true, writeByteString(buf, raw)
+		}
+		_, err = buf.Write(raw)
+		return true, err
+	case driver.Valuer:
+		val, err := p.Value()
+		if err != nil {
+			return true, err
+		}
+		if _, ok := val.(driver.Valuer); ok {
+			return true, fmt.Errorf("Value of %T is itself a driver.Valuer.", param)
+		}
+		return true, marshalParam(buf, val, prec)
+	}
+	return false, nil
+}
+
+// nilValueReceiver reports whether param is a nil pointer whose element
+// type implements iface, so that calling the method would panic. As in
+// database/sql, such a param is NULL.
+func nilValueReceiver(param interface{}, iface reflect.Type) bool {
+	rv := reflect.ValueOf(param)
+	return rv.Kind() == reflect.Ptr && rv.IsNil() && rv.Type().Elem().Implements(iface)
+}
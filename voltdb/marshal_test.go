@@ -0,0 +1,105 @@
+package voltdb
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"testing"
+)
+
+// colour is an enum stored as a VARCHAR.
+type colour int
+
+func (c *colour) UnmarshalVolt(colType ColumnType, raw []byte) error {
+	switch string(raw) {
+	case "red":
+		*c = 1
+	case "blue":
+		*c = 2
+	default:
+		return fmt.Errorf("unknown colour %q", raw)
+	}
+	return nil
+}
+
+func (c colour) MarshalVolt() (ColumnType, []byte, error) {
+	return TypeString, []byte([]string{"", "red", "blue"}[c]), nil
+}
+
+// cents is money stored as a BIGINT, converted by database/sql interfaces.
+type cents struct{ amount int64 }
+
+func (c *cents) Scan(src interface{}) error {
+	v, ok := src.(int64)
+	if !ok {
+		return fmt.Errorf("cents from %T", src)
+	}
+	c.amount = v
+	return nil
+}
+
+func (c cents) Value() (driver.Value, error) {
+	return c.amount, nil
+}
+
+func TestUnmarshalerAndScanner(t *testing.T) {
+	table := testTable([]string{"COLOUR", "PRICE", "NOTE"},
+		[]int8{vt_STRING, vt_INT, vt_STRING},
+		[]interface{}{"blue", int32(250), "x"})
+	var row struct {
+		Colour colour
+		Price  cents
+		Note   sql.NullString
+	}
+	if err := table.Next(&row); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if row.Colour != 2 || row.Price.amount != 250 || !row.Note.Valid || row.Note.String != "x" {
+		t.Errorf("Bad row %#v", row)
+	}
+}
+
+func TestMarshalerAndValuer(t *testing.T) {
+	var b bytes.Buffer
+	if err := marshalParam(&b, colour(1), TimestampError); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if vt, _ := readByte(&b); vt != vt_STRING {
+		t.Errorf("Bad marshaler type %v", vt)
+	}
+	if s, _ := readString(&b); s != "red" {
+		t.Errorf("Bad marshaler value %v", s)
+	}
+
+	b.Reset()
+	if err := marshalParam(&b, cents{99}, TimestampError); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if vt, _ := readByte(&b); vt != vt_LONG {
+		t.Errorf("Bad valuer type %v", vt)
+	}
+	if v, _ := readLong(&b); v != 99 {
+		t.Errorf("Bad valuer value %v", v)
+	}
+
+	b.Reset()
+	if err := marshalParam(&b, sql.NullInt64{}, TimestampError); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if vt, _ := readByte(&b); vt != vt_NULL || b.Len() != 0 {
+		t.Errorf("Expected NULL parameter, have type %v", vt)
+	}
+}
+
+func TestMarshalNilValueReceiver(t *testing.T) {
+	for _, param := range []interface{}{(*cents)(nil), (*colour)(nil), (*sql.NullInt64)(nil)} {
+		var b bytes.Buffer
+		if err := marshalParam(&b, param, TimestampError); err != nil {
+			t.Fatalf("Unexpected error for %T: %v", param, err)
+		}
+		if vt, _ := readByte(&b); vt != vt_NULL || b.Len() != 0 {
+			t.Errorf("Expected NULL parameter for %T, have type %v", param, vt)
+		}
+	}
+}
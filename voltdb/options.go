@@ -0,0 +1,71 @@
+package voltdb
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrTimeout is returned, wrapped, by calls that did not receive a
+// response within their timeout.
+var ErrTimeout = errors.New("Call timed out")
+
+// Request priorities range from MaxPriority to MinPriority.
+const (
+	MaxPriority = 1
+	MinPriority = 8
+)
+
+// CallOption configures a single invocation. CallOptions are passed to
+// Conn.Call mixed in with the procedure parameters and are not sent as
+// parameters.
+type CallOption func(*callOptions)
+
+type callOptions struct {
+	timeout   time.Duration
+	priority  int
+	handle    int64
+	hasHandle bool
+
+	// precision is the connection's TimestampPrecision.
+	precision TimestampPrecision
+}
+
+// WithTimeout bounds the call to d. The client stops waiting for the
+// response after d and the server is asked to cancel the work when it
+// runs longer than d. It overrides the connection's ReadTimeout.
+func WithTimeout(d time.Duration) CallOption {
+	return func(opts *callOptions) { opts.timeout = d }
+}
+
+// WithPriority sets the request priority, from MaxPriority (1) to
+// MinPriority (8).
+func WithPriority(p int) CallOption {
+	return func(opts *callOptions) { opts.priority = p }
+}
+
+// WithHandle sets the client handle sent with the call instead of one
+// assigned by the Conn. Handles must be unique among outstanding calls.
+func WithHandle(h int64) CallOption {
+	return func(opts *callOptions) { opts.handle, opts.hasHandle = h, true }
+}
+
+// splitCallOptions separates CallOptions from procedure parameters.
+func splitCallOptions(args []interface{}) ([]interface{}, callOptions, error) {
+	var opts callOptions
+	params := args[:0:0]
+	for _, arg := range args {
+		if opt, ok := arg.(CallOption); ok {
+			opt(&opts)
+		} else {
+			params = append(params, arg)
+		}
+	}
+	if opts.timeout < 0 {
+		return nil, opts, fmt.Errorf("Invalid call timeout %v.", opts.timeout)
+	}
+	if opts.priority != 0 && (opts.priority < MaxPriority || opts.priority > MinPriority) {
+		return nil, opts, fmt.Errorf("Invalid call priority %d.", opts.priority)
+	}
+	return params, opts, nil
+}
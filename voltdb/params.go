@@ -0,0 +1,225 @@
+package voltdb
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// params.go builds procedure parameter lists from structs and maps, for
+// procedures with too many parameters to comfortably list in a Call.
+
+// CallStruct invokes procedure with the exported fields of the struct,
+// or struct pointer, v as its parameters. Fields are passed in the
+// order of their `volt:"N"` tags, numbered from 1, or in declaration
+// order if no field is numbered. Fields tagged `volt:"-"` are omitted,
+// the fields of embedded structs are expanded in place, and nil
+// pointer fields are passed as NULL.
+func (conn *Conn) CallStruct(procedure string, v interface{}, opts ...CallOption) (*Response, error) {
+	params, err := structParams(v)
+	if err != nil {
+		return nil, err
+	}
+	for _, opt := range opts {
+		params = append(params, opt)
+	}
+	return conn.Call(procedure, params...)
+}
+
+// structParams returns the parameter list CallStruct passes for v.
+func structParams(v interface{}) ([]interface{}, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("Must supply a struct to expand into parameters.")
+	}
+
+	type param struct {
+		position int // 0 if not numbered
+		value    reflect.Value
+	}
+	var params []param
+	var collect func(v reflect.Value) error
+	collect = func(v reflect.Value) error {
+		for idx := 0; idx < v.NumField(); idx++ {
+			field, fv := v.Type().Field(idx), v.Field(idx)
+			tag := field.Tag.Get(tagName)
+			if tag == "-" {
+				continue
+			}
+			if field.Anonymous && tag == "" {
+				if fv.Kind() == reflect.Ptr && fv.Type().Elem().Kind() == reflect.Struct &&
+					!isLeafType(fv.Type().Elem()) {
+					if fv.IsNil() {
+						continue
+					}
+					fv = fv.Elem()
+				}
+				if fv.Kind() == reflect.Struct && !isLeafType(fv.Type()) {
+					if err := collect(fv); err != nil {
+						return err
+					}
+					continue
+				}
+			}
+			if field.PkgPath != "" {
+				continue
+			}
+			p := param{value: fv}
+			if tag != "" {
+				position, err := strconv.Atoi(tag)
+				if err != nil || position < 1 {
+					return fmt.Errorf("Field %v has tag %q, expected a parameter number.", field.Name, tag)
+				}
+				p.position = position
+			}
+			params = append(params, p)
+		}
+		return nil
+	}
+	if err := collect(rv); err != nil {
+		return nil, err
+	}
+
+	numbered := 0
+	for _, p := range params {
+		if p.position > 0 {
+			numbered++
+		}
+	}
+	if numbered > 0 {
+		if numbered != len(params) {
+			return nil, fmt.Errorf("Either every parameter field of %v must be numbered or none.", rv.Type())
+		}
+		sort.SliceStable(params, func(i, j int) bool { return params[i].position < params[j].position })
+		for idx, p := range params {
+			if p.position != idx+1 {
+				return nil, fmt.Errorf("Parameters of %v are not numbered 1 to %d.", rv.Type(), len(params))
+			}
+		}
+	}
+
+	values := make([]interface{}, len(params))
+	for idx, p := range params {
+		if p.value.Kind() == reflect.Ptr && p.value.Type() != ratPtrType {
+			if p.value.IsNil() {
+				continue
+			}
+			p.value = p.value.Elem()
+		}
+		values[idx] = p.value.Interface()
+	}
+	return values, nil
+}
+
+// procedureColumn is a row of @SystemCatalog PROCEDURECOLUMNS.
+type procedureColumn struct {
+	ProcedureName   string `volt:"PROCEDURE_NAME"`
+	ColumnName      string `volt:"COLUMN_NAME"`
+	OrdinalPosition int    `volt:"ORDINAL_POSITION"`
+}
+
+// ParamsFromMap returns the values of params, keyed by parameter name,
+// in the order procedure declares its parameters. The parameter names
+// are read from the catalog with @SystemCatalog and matched ignoring
+// case. Every parameter must have a value, and every value a parameter.
+//
+// The Conn keeps the parameter names of every procedure until it
+// reconnects, reading the catalog again for an unknown procedure or
+// when params do not match the names kept.
+func (conn *Conn) ParamsFromMap(procedure string, params map[string]interface{}) ([]interface{}, error) {
+	signature, cached, err := conn.procedureSignature(procedure, false)
+	if err != nil {
+		return nil, err
+	}
+	values, err := orderParams(procedure, signature, params)
+	if err != nil && cached {
+		// the procedure may have changed since the catalog was read.
+		if signature, _, err = conn.procedureSignature(procedure, true); err != nil {
+			return nil, err
+		}
+		values, err = orderParams(procedure, signature, params)
+	}
+	return values, err
+}
+
+// procedureSignature returns the parameters of procedure in order,
+// reading the catalog if they are not kept or reload is set. It
+// reports whether they were kept.
+func (conn *Conn) procedureSignature(procedure string, reload bool) ([]procedureColumn, bool, error) {
+	key := strings.ToUpper(procedure)
+	conn.mu.Lock()
+	signature, ok := conn.signatures[key]
+	conn.mu.Unlock()
+	if ok && !reload {
+		return signature, true, nil
+	}
+
+	rsp, err := conn.Call("@SystemCatalog", "PROCEDURECOLUMNS")
+	if err != nil {
+		return nil, false, err
+	}
+	if rsp.Status() != SUCCESS {
+		return nil, false, fmt.Errorf("Reading the parameters of %v failed: %v", procedure, rsp.StatusString())
+	}
+	columns, err := CollectRows[procedureColumn](rsp.Table(0))
+	if err != nil {
+		return nil, false, err
+	}
+	signatures := procedureSignatures(columns)
+	conn.mu.Lock()
+	conn.signatures = signatures
+	conn.mu.Unlock()
+	return signatures[key], false, nil
+}
+
+// forgetSignatures discards the kept procedure parameters, to be read
+// again from the catalog.
+func (conn *Conn) forgetSignatures() {
+	conn.mu.Lock()
+	conn.signatures = nil
+	conn.mu.Unlock()
+}
+
+// procedureSignatures groups catalog columns by upper case procedure
+// name, each in parameter order.
+func procedureSignatures(columns []procedureColumn) map[string][]procedureColumn {
+	signatures := make(map[string][]procedureColumn)
+	for _, col := range columns {
+		key := strings.ToUpper(col.ProcedureName)
+		signatures[key] = append(signatures[key], col)
+	}
+	for _, signature := range signatures {
+		sort.Slice(signature, func(i, j int) bool {
+			return signature[i].OrdinalPosition < signature[j].OrdinalPosition
+		})
+	}
+	return signatures
+}
+
+// orderParams orders params by the procedure's signature.
+func orderParams(procedure string, signature []procedureColumn, params map[string]interface{}) ([]interface{}, error) {
+	byName := make(map[string]interface{}, len(params))
+	for name, val := range params {
+		byName[strings.ToUpper(name)] = val
+	}
+	values := make([]interface{}, len(signature))
+	for idx, col := range signature {
+		val, ok := byName[strings.ToUpper(col.ColumnName)]
+		if !ok {
+			return nil, fmt.Errorf("No value for parameter %v of %v.", col.ColumnName, procedure)
+		}
+		values[idx] = val
+		delete(byName, strings.ToUpper(col.ColumnName))
+	}
+	for name := range params {
+		if _, ok := byName[strings.ToUpper(name)]; ok {
+			return nil, fmt.Errorf("Procedure %v has no parameter %v.", procedure, name)
+		}
+	}
+	return values, nil
+}
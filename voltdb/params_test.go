@@ -0,0 +1,157 @@
+package voltdb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"testing"
+)
+
+type paramsBase struct {
+	Region string
+}
+
+func TestStructParams(t *testing.T) {
+	name := "widget"
+	type ordered struct {
+		paramsBase
+		ID     int32
+		Name   *string
+		Note   *string
+		Ignore int `volt:"-"`
+		hidden int
+	}
+	params, err := structParams(&ordered{paramsBase{"east"}, 7, &name, nil, 1, 2})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if fmt.Sprint(params) != "[east 7 widget <nil>]" {
+		t.Errorf("Bad declaration order params %v", params)
+	}
+
+	type numbered struct {
+		Second string `volt:"2"`
+		First  int32  `volt:"1"`
+	}
+	params, err = structParams(numbered{"b", 1})
+	if err != nil || fmt.Sprint(params) != "[1 b]" {
+		t.Errorf("Bad numbered params %v: %v", params, err)
+	}
+
+	bad := []interface{}{
+		struct {
+			A int `volt:"1"`
+			B int
+		}{},
+		struct {
+			A int `volt:"1"`
+			B int `volt:"3"`
+		}{},
+		struct {
+			A int `volt:"ID"`
+		}{},
+		"not a struct",
+	}
+	for _, v := range bad {
+		if _, err := structParams(v); err == nil {
+			t.Errorf("Expected error expanding %#v", v)
+		}
+	}
+}
+
+func TestCallStruct(t *testing.T) {
+	conn, server := pipeConn(t)
+	defer conn.Close()
+
+	received := make(chan *bytes.Buffer, 1)
+	go func() {
+		msg, err := readTestMessage(server)
+		if err != nil {
+			return
+		}
+		received <- bytes.NewBuffer(msg.Bytes())
+		readString(msg)
+		handle, _ := readLong(msg)
+		writeTestResponse(server, handle)
+	}()
+	if _, err := conn.CallStruct("Add", struct {
+		ID   int32
+		Name string
+	}{3, "three"}); err != nil {
+		t.Fatalf("Unexpected call error: %v", err)
+	}
+
+	msg := <-received
+	readString(msg)
+	readLong(msg)
+	if count, _ := readShort(msg); count != 2 {
+		t.Fatalf("Bad parameter count %v", count)
+	}
+	var values []interface{}
+	for i := 0; i < 2; i++ {
+		vt, _ := readByte(msg)
+		val, _ := readValue(msg, vt, nil)
+		values = append(values, val)
+	}
+	if fmt.Sprint(values) != "[3 three]" {
+		t.Errorf("Bad parameters %v", values)
+	}
+}
+
+func TestOrderParams(t *testing.T) {
+	columns := []procedureColumn{
+		{"Other", "X", 1},
+		{"Add", "NAME", 2},
+		{"Add", "ID", 1},
+	}
+	signature := procedureSignatures(columns)["ADD"]
+	params, err := orderParams("Add", signature, map[string]interface{}{"name": "n", "id": 1})
+	if err != nil || fmt.Sprint(params) != "[1 n]" {
+		t.Errorf("Bad ordered params %v: %v", params, err)
+	}
+	if _, err := orderParams("Add", signature, map[string]interface{}{"id": 1}); err == nil {
+		t.Errorf("Expected error for a missing parameter")
+	}
+	if _, err := orderParams("Add", signature, map[string]interface{}{"id": 1, "name": "n", "x": 2}); err == nil {
+		t.Errorf("Expected error for an unknown parameter")
+	}
+}
+
+func TestParamsFromMapKeepsSignatures(t *testing.T) {
+	b := NewTableBuilder()
+	b.AddColumn("PROCEDURE_NAME", TypeString)
+	b.AddColumn("COLUMN_NAME", TypeString)
+	b.AddColumn("ORDINAL_POSITION", TypeInteger)
+	b.AddRow("Add", "NAME", 2)
+	b.AddRow("Add", "ID", 1)
+	catalog := b.Build()
+
+	calls := make(chan [2]string, 10)
+	dialer := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		client, server := net.Pipe()
+		go serveTables(server, calls, catalog)
+		return client, nil
+	}
+	conn, err := DialWithOptions(WithAddresses("pipe:21212"), WithDialer(dialer))
+	if err != nil {
+		t.Fatalf("Unexpected connection error: %v", err)
+	}
+	defer conn.Close()
+
+	for i := 0; i < 2; i++ {
+		params, err := conn.ParamsFromMap("add", map[string]interface{}{"name": "n", "id": 1})
+		if err != nil || fmt.Sprint(params) != "[1 n]" {
+			t.Errorf("Bad ordered params %v: %v", params, err)
+		}
+	}
+	if len(calls) != 1 {
+		t.Errorf("Expected the catalog to be read once, read %d times", len(calls))
+	}
+	if _, err := conn.ParamsFromMap("Add", map[string]interface{}{"id": 1}); err == nil {
+		t.Errorf("Expected error for a missing parameter")
+	}
+	if len(calls) != 2 {
+		t.Errorf("Expected a mismatch to read the catalog again, read %d times", len(calls))
+	}
+}
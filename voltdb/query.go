@@ -0,0 +1,166 @@
+package voltdb
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// query.go runs @AdHoc SQL with named parameters, rewriting them to the
+// positional ? placeholders VoltDB understands.
+
+// NamedArg binds a value to the :name placeholders of a Query.
+type NamedArg struct {
+	Name  string
+	Value interface{}
+}
+
+// Named returns a NamedArg binding value to :name.
+func Named(name string, value interface{}) NamedArg {
+	return NamedArg{Name: name, Value: value}
+}
+
+// Query runs the SQL statement query with @AdHoc. Values are bound to
+// the statement's :name placeholders by args, which are NamedArg or
+// sql.NamedArg values and may be mixed with CallOptions. A slice value,
+// other than []byte, expands to a comma separated list of its elements
+// for use in an IN list. Every placeholder must be bound and every
+// argument used.
+func Query(conn *Conn, query string, args ...interface{}) (*Response, error) {
+	named := make(map[string]interface{})
+	var opts []interface{}
+	for _, arg := range args {
+		var name string
+		var value interface{}
+		switch a := arg.(type) {
+		case NamedArg:
+			name, value = a.Name, a.Value
+		case sql.NamedArg:
+			name, value = a.Name, a.Value
+		case CallOption:
+			opts = append(opts, a)
+			continue
+		default:
+			return nil, fmt.Errorf("Query arguments must be named, have %T.", arg)
+		}
+		if _, ok := named[name]; ok {
+			return nil, fmt.Errorf("Query argument %v is bound more than once.", name)
+		}
+		named[name] = value
+	}
+
+	stmt, params, err := bindNamed(query, named)
+	if err != nil {
+		return nil, err
+	}
+	return conn.Call("@AdHoc", append(append([]interface{}{stmt}, params...), opts...)...)
+}
+
+// bindNamed rewrites the :name placeholders of query to ? and returns
+// the values for them in order. Placeholders are not recognized in
+// quoted strings and identifiers or in comments.
+func bindNamed(query string, named map[string]interface{}) (string, []interface{}, error) {
+	var stmt strings.Builder
+	var params []interface{}
+	used := make(map[string]bool)
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"':
+			end := i + 1
+			for end < len(query) {
+				if query[end] == c {
+					// a doubled quote is an escaped quote.
+					if end+1 < len(query) && query[end+1] == c {
+						end += 2
+						continue
+					}
+					break
+				}
+				end++
+			}
+			if end >= len(query) {
+				return "", nil, fmt.Errorf("Unterminated quote in query.")
+			}
+			stmt.WriteString(query[i : end+1])
+			i = end + 1
+		case strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = len(query) - i
+			}
+			stmt.WriteString(query[i : i+end])
+			i += end
+		case strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				return "", nil, fmt.Errorf("Unterminated comment in query.")
+			}
+			stmt.WriteString(query[i : i+end+4])
+			i += end + 4
+		case c == '?':
+			return "", nil, fmt.Errorf("Query mixes ? and named placeholders; use only named.")
+		case strings.HasPrefix(query[i:], "::"):
+			stmt.WriteString("::")
+			i += 2
+		case c == ':' && i+1 < len(query) && isNameStart(query[i+1]):
+			end := i + 2
+			for end < len(query) && isNamePart(query[end]) {
+				end++
+			}
+			name := query[i+1 : end]
+			value, ok := named[name]
+			if !ok {
+				return "", nil, fmt.Errorf("Query placeholder :%v is not bound.", name)
+			}
+			used[name] = true
+			values, err := expandParam(name, value)
+			if err != nil {
+				return "", nil, err
+			}
+			for idx := range values {
+				if idx > 0 {
+					stmt.WriteString(", ")
+				}
+				stmt.WriteByte('?')
+			}
+			params = append(params, values...)
+			i = end
+		default:
+			stmt.WriteByte(c)
+			i++
+		}
+	}
+	for name := range named {
+		if !used[name] {
+			return "", nil, fmt.Errorf("Query has no placeholder :%v.", name)
+		}
+	}
+	return stmt.String(), params, nil
+}
+
+// expandParam returns the elements of a slice or array value, other
+// than []byte, or the value itself.
+func expandParam(name string, value interface{}) ([]interface{}, error) {
+	rv := reflect.ValueOf(value)
+	if k := rv.Kind(); (k != reflect.Slice && k != reflect.Array) || rv.Type().Elem().Kind() == reflect.Uint8 {
+		return []interface{}{value}, nil
+	}
+	if rv.Len() == 0 {
+		return nil, fmt.Errorf("Query argument %v is an empty list.", name)
+	}
+	values := make([]interface{}, rv.Len())
+	for idx := range values {
+		values[idx] = rv.Index(idx).Interface()
+	}
+	return values, nil
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+func isNamePart(c byte) bool {
+	return isNameStart(c) || ('0' <= c && c <= '9')
+}
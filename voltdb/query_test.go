@@ -0,0 +1,89 @@
+package voltdb
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"testing"
+)
+
+func TestBindNamed(t *testing.T) {
+	named := map[string]interface{}{
+		"key":  "k1",
+		"ids":  []int32{1, 2, 3},
+		"blob": []byte{1, 2},
+	}
+	stmt, params, err := bindNamed(
+		"select * from store where key = :key and id in (:ids) -- :comment\n"+
+			"and data = :blob and note = 'it''s :quoted' /* :block */ or key = :key", named)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := "select * from store where key = ? and id in (?, ?, ?) -- :comment\n" +
+		"and data = ? and note = 'it''s :quoted' /* :block */ or key = ?"
+	if stmt != expected {
+		t.Errorf("Bad statement %q", stmt)
+	}
+	if fmt.Sprint(params) != "[k1 1 2 3 [1 2] k1]" {
+		t.Errorf("Bad params %v", params)
+	}
+
+	bad := []struct {
+		query string
+		named map[string]interface{}
+	}{
+		{"select :missing", nil},
+		{"select 1", map[string]interface{}{"unused": 1}},
+		{"select :a, ?", map[string]interface{}{"a": 1}},
+		{"select * from t where id in (:ids)", map[string]interface{}{"ids": []int{}}},
+		{"select ':a", map[string]interface{}{"a": 1}},
+		{"select /* :a", map[string]interface{}{"a": 1}},
+	}
+	for _, b := range bad {
+		if _, _, err := bindNamed(b.query, b.named); err == nil {
+			t.Errorf("Expected error binding %q", b.query)
+		}
+	}
+}
+
+func TestQuery(t *testing.T) {
+	conn, server := pipeConn(t)
+	defer conn.Close()
+
+	received := make(chan *bytes.Buffer, 1)
+	go func() {
+		msg, err := readTestMessage(server)
+		if err != nil {
+			return
+		}
+		received <- bytes.NewBuffer(msg.Bytes())
+		readString(msg)
+		handle, _ := readLong(msg)
+		writeTestResponse(server, handle)
+	}()
+	if _, err := Query(conn, "select * from store where key = :key or key = :other",
+		Named("key", "a"), sql.Named("other", "b")); err != nil {
+		t.Fatalf("Unexpected call error: %v", err)
+	}
+
+	msg := <-received
+	proc, _ := readString(msg)
+	readLong(msg)
+	count, _ := readShort(msg)
+	var values []interface{}
+	for i := 0; i < int(count); i++ {
+		vt, _ := readByte(msg)
+		val, _ := readValue(msg, vt, nil)
+		values = append(values, val)
+	}
+	if proc != "@AdHoc" || fmt.Sprint(values) != "[select * from store where key = ? or key = ? a b]" {
+		t.Errorf("Bad invocation %v %v", proc, values)
+	}
+
+	if _, err := Query(conn, "select :a", Named("a", 1), Named("a", 2)); err == nil {
+		t.Errorf("Expected error binding a name twice")
+	}
+	if _, err := Query(conn, "select :a", 1); err == nil {
+		t.Errorf("Expected error for a positional argument")
+	}
+}
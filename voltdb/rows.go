@@ -0,0 +1,70 @@
+package voltdb
+
+import (
+	"fmt"
+	"iter"
+)
+
+// rows.go has typed helpers over Table.Next for row structs of type T.
+
+// Rows returns an iterator over the table's remaining rows decoded
+// into T, a struct type populated as by Table.Next. Iteration stops
+// after the first error, which is yielded with the zero T.
+func Rows[T any](table *Table) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for table.HasNext() {
+			var row T
+			if err := table.Next(&row); err != nil {
+				yield(row, err)
+				return
+			}
+			if !yield(row, nil) {
+				return
+			}
+		}
+	}
+}
+
+// CollectRows decodes the table's remaining rows into a slice of T.
+func CollectRows[T any](table *Table) ([]T, error) {
+	rows := make([]T, 0, table.RowCount()-table.Position())
+	for row, err := range Rows[T](table) {
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// One decodes the only row of a single-row table into T. It does not
+// move the table's read position.
+func One[T any](table *Table) (T, error) {
+	var row T
+	if table.RowCount() != 1 {
+		return row, fmt.Errorf("Expected one row, have %d.", table.RowCount())
+	}
+	r, err := table.Row(0)
+	if err != nil {
+		return row, err
+	}
+	err = r.Decode(&row)
+	return row, err
+}
+
+// Scalar returns the value of a one-row, one-column table, such as the
+// result of a procedure returning a long or of "select count(*)",
+// converted to T as Table.Scan does. It does not move the read position.
+func Scalar[T any](table *Table) (T, error) {
+	var val T
+	if table.RowCount() != 1 || table.ColumnCount() != 1 {
+		return val, fmt.Errorf("Expected one row and one column, have %d rows and %d columns.",
+			table.RowCount(), table.ColumnCount())
+	}
+	r, err := table.Row(0)
+	if err != nil {
+		return val, err
+	}
+	err = r.Scan(&val)
+	return val, err
+}
@@ -0,0 +1,51 @@
+package voltdb
+
+import "testing"
+
+func TestRowsAndCollectRows(t *testing.T) {
+	table := cursorTestTable(t)
+	var keys string
+	for row, err := range Rows[cursorRow](table) {
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		keys += row.Key
+		break
+	}
+	rest, err := CollectRows[cursorRow](table)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if keys != "a" || len(rest) != 2 || rest[1].Value != 3 {
+		t.Errorf("Bad rows %v %#v", keys, rest)
+	}
+
+	table.Reset()
+	for _, err := range Rows[struct{ Key int }](table) {
+		if err == nil {
+			t.Errorf("Expected conversion error")
+		}
+	}
+}
+
+func TestOneAndScalar(t *testing.T) {
+	table := testTable([]string{""}, []int8{vt_LONG}, []interface{}{int64(6)})
+	count, err := Scalar[int](table)
+	if err != nil || count != 6 {
+		t.Errorf("Bad scalar %v %v", err, count)
+	}
+	asString, err := Scalar[string](table)
+	if err != nil || asString != "6" {
+		t.Errorf("Bad scalar %v %v", err, asString)
+	}
+
+	if _, err = One[cursorRow](cursorTestTable(t)); err == nil {
+		t.Errorf("Expected error for a multi-row table")
+	}
+	single := testTable([]string{"KEY", "VALUE"}, []int8{vt_STRING, vt_INT},
+		[]interface{}{"a", int32(1)})
+	row, err := One[cursorRow](single)
+	if err != nil || row.Key != "a" || row.Value != 1 {
+		t.Errorf("Bad row %v %#v", err, row)
+	}
+}
@@ -0,0 +1,86 @@
+package voltdb
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// scan.go reads rows without a row struct, for results whose shape is
+// only known at run time.
+
+// Scan reads the next row into dest, one pointer per column in column
+// order, like database/sql's Rows.Scan. A nil dest skips its column.
+// Values are converted as they are for struct fields by Next.
+func (table *Table) Scan(dest ...interface{}) error {
+	if len(dest) != len(table.columnTypes) {
+		return fmt.Errorf("Scan expects %d destinations, have %d.",
+			len(table.columnTypes), len(dest))
+	}
+	decoders := make([]decodeFunc, len(dest))
+	values := make([]reflect.Value, len(dest))
+	for idx, d := range dest {
+		if d == nil {
+			continue
+		}
+		rv := reflect.ValueOf(d)
+		if rv.Kind() != reflect.Ptr || rv.IsNil() {
+			return fmt.Errorf("Scan destination %d is not a non-nil pointer.", idx)
+		}
+		field := reflect.StructField{Name: fmt.Sprintf("dest[%d]", idx), Type: rv.Type().Elem()}
+		decode, err := fieldDecoder(table.columnNames[idx], table.columnTypes[idx], field, table.location)
+		if err != nil {
+			return err
+		}
+		decoders[idx], values[idx] = decode, rv.Elem()
+	}
+
+	r, err := table.startRow()
+	if err != nil {
+		return err
+	}
+	for idx, vt := range table.columnTypes {
+		if decoders[idx] == nil {
+			err = skipValue(r, vt)
+		} else {
+			err = decoders[idx](r, values[idx])
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return table.endRow(r)
+}
+
+// NextValues returns the values of the next row in column order. Each
+// value has the Go type of its column: int8, int16, int32 or int64 for
+// the integer types, bool for BOOLEAN, float64, string, time.Time,
+// []byte for VARBINARY, *big.Rat for DECIMAL, GeographyPoint and
+// Geography for the geospatial types and *Table for embedded tables.
+// NULL values are nil.
+func (table *Table) NextValues() ([]interface{}, error) {
+	r, err := table.startRow()
+	if err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, len(table.columnTypes))
+	for idx, vt := range table.columnTypes {
+		if values[idx], err = readValue(r, vt, table.location); err != nil {
+			return nil, err
+		}
+	}
+	return values, table.endRow(r)
+}
+
+// NextMap returns the values of the next row keyed by column name.
+// Values have the types described by NextValues.
+func (table *Table) NextMap() (map[string]interface{}, error) {
+	values, err := table.NextValues()
+	if err != nil {
+		return nil, err
+	}
+	row := make(map[string]interface{}, len(values))
+	for idx, val := range values {
+		row[table.columnNames[idx]] = val
+	}
+	return row, nil
+}
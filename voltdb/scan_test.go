@@ -0,0 +1,86 @@
+package voltdb
+
+import (
+	"testing"
+)
+
+func scanTestTable(t *testing.T) *Table {
+	return testBuilder(t, []string{"KEY", "VALUE", "COUNT"},
+		[]ColumnType{TypeString, TypeString, TypeInteger},
+		[]interface{}{"a", "b", 1},
+		[]interface{}{"c", "d", nil}).Build()
+}
+
+func TestScan(t *testing.T) {
+	table := scanTestTable(t)
+	var key string
+	var count int64
+	if err := table.Scan(&key, nil, &count); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if key != "a" || count != 1 {
+		t.Errorf("Bad scan %v %v", key, count)
+	}
+	if err := table.Scan(&key); err == nil {
+		t.Errorf("Expected error scanning too few destinations")
+	}
+	var countPtr *int
+	if err := table.Scan(&key, nil, &countPtr); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if key != "c" || countPtr != nil {
+		t.Errorf("Bad scan %v %v", key, countPtr)
+	}
+}
+
+func TestNextValuesAndMap(t *testing.T) {
+	table := scanTestTable(t)
+	values, err := table.NextValues()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if values[0] != "a" || values[1] != "b" || values[2] != int32(1) {
+		t.Errorf("Bad values %#v", values)
+	}
+	row, err := table.NextMap()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if row["KEY"] != "c" || row["VALUE"] != "d" || row["COUNT"] != nil {
+		t.Errorf("Bad row %#v", row)
+	}
+	if _, err = table.NextMap(); err == nil {
+		t.Errorf("Expected error reading past the last row")
+	}
+}
+
+func TestRowAfterFailedRow(t *testing.T) {
+	newTable := func() *Table {
+		return testTable([]string{"N", "NAME"}, []int8{vt_LONG, vt_STRING},
+			[]interface{}{int64(1000), "big"},
+			[]interface{}{int64(7), "small"})
+	}
+	type Row struct {
+		N    int8
+		Name string
+	}
+
+	table := newTable()
+	var row Row
+	if err := table.Next(&row); err == nil {
+		t.Errorf("Expected overflow error")
+	}
+	if err := table.Next(&row); err != nil || row.N != 7 || row.Name != "small" {
+		t.Errorf("Bad row after failed row %#v: %v", row, err)
+	}
+
+	table = newTable()
+	var n int8
+	var name string
+	if err := table.Scan(&n, &name); err == nil {
+		t.Errorf("Expected overflow error")
+	}
+	if err := table.Scan(&n, &name); err != nil || n != 7 || name != "small" {
+		t.Errorf("Bad scan after failed row %v %v: %v", n, name, err)
+	}
+}
@@ -0,0 +1,175 @@
+package voltdb
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// text.go renders tables as aligned text in the style of sqlcmd.
+
+// TextOptions control how WriteText renders a table.
+type TextOptions struct {
+	// MaxColumnWidth, if positive, truncates longer values, marking the
+	// cut with "...".
+	MaxColumnWidth int
+	// MaxRows, if positive, limits the rows shown. The row count still
+	// reports every row.
+	MaxRows int
+	// Null is shown for NULL values, "NULL" if empty.
+	Null string
+}
+
+// String renders the table as WriteText does with default options.
+func (table *Table) String() string {
+	var buf bytes.Buffer
+	table.WriteText(&buf, TextOptions{})
+	return buf.String()
+}
+
+// WriteText writes every row of the table, whatever its read position,
+// as aligned text: a header of column names, a row of dashes, one line
+// per row and the row count. Numbers are aligned right.
+func (table *Table) WriteText(w io.Writer, opts TextOptions) error {
+	if opts.Null == "" {
+		opts.Null = "NULL"
+	}
+	cursor := table.Cursor()
+	widths := make([]int, len(table.columnNames))
+	for idx, name := range table.columnNames {
+		widths[idx] = textWidth(name, opts)
+	}
+	var cells [][]string
+	for cursor.HasNext() && (opts.MaxRows <= 0 || len(cells) < opts.MaxRows) {
+		values, err := cursor.NextValues()
+		if err != nil {
+			return err
+		}
+		row := make([]string, len(values))
+		for idx, val := range values {
+			row[idx] = truncateText(textValue(val, opts.Null), opts)
+			widths[idx] = max(widths[idx], utf8.RuneCountInString(row[idx]))
+		}
+		cells = append(cells, row)
+	}
+
+	var buf bytes.Buffer
+	for idx, name := range table.columnNames {
+		writeCell(&buf, truncateText(name, opts), widths[idx], false)
+	}
+	buf.WriteByte('\n')
+	for idx := range table.columnNames {
+		writeCell(&buf, strings.Repeat("-", widths[idx]), widths[idx], false)
+	}
+	buf.WriteByte('\n')
+	for _, row := range cells {
+		for idx, cell := range row {
+			writeCell(&buf, cell, widths[idx], isNumericType(table.columnTypes[idx]))
+		}
+		buf.WriteByte('\n')
+	}
+	if more := table.RowCount() - len(cells); more > 0 {
+		fmt.Fprintf(&buf, "... %d more %v\n", more, plural(more, "row"))
+	}
+	fmt.Fprintf(&buf, "\n(Returned %d %v)\n", table.RowCount(), plural(table.RowCount(), "row"))
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// String renders the response as WriteText does with default options.
+func (rsp *Response) String() string {
+	var buf bytes.Buffer
+	rsp.WriteText(&buf, TextOptions{})
+	return buf.String()
+}
+
+// WriteText writes the status of a failed response, then each result
+// table as Table.WriteText does, separated by blank lines.
+func (rsp *Response) WriteText(w io.Writer, opts TextOptions) error {
+	if rsp.Status() != SUCCESS {
+		if _, err := fmt.Fprintf(w, "%v: %v\n", rsp.Status(), rsp.StatusString()); err != nil {
+			return err
+		}
+	}
+	for idx := range rsp.tables {
+		if idx > 0 {
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+		}
+		if err := rsp.tables[idx].WriteText(w, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// textValue renders a value returned by readValue.
+func textValue(val interface{}, null string) string {
+	switch v := val.(type) {
+	case nil:
+		return null
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case time.Time:
+		return v.Format("2006-01-02 15:04:05.000000")
+	case []byte:
+		return strings.ToUpper(hex.EncodeToString(v))
+	case *big.Rat:
+		return v.FloatString(decimalScale)
+	case *Table:
+		return fmt.Sprintf("<table of %d %v>", v.RowCount(), plural(v.RowCount(), "row"))
+	}
+	return fmt.Sprint(val)
+}
+
+func isNumericType(vt int8) bool {
+	switch vt {
+	case vt_TINYINT, vt_SHORT, vt_INT, vt_LONG, vt_FLOAT, vt_DECIMAL:
+		return true
+	}
+	return false
+}
+
+func textWidth(s string, opts TextOptions) int {
+	return utf8.RuneCountInString(truncateText(s, opts))
+}
+
+// truncateText shortens s to opts.MaxColumnWidth runes.
+func truncateText(s string, opts TextOptions) string {
+	if opts.MaxColumnWidth <= 0 || utf8.RuneCountInString(s) <= opts.MaxColumnWidth {
+		return s
+	}
+	if opts.MaxColumnWidth <= 3 {
+		return string([]rune(s)[:opts.MaxColumnWidth])
+	}
+	return string([]rune(s)[:opts.MaxColumnWidth-3]) + "..."
+}
+
+// writeCell writes s padded to width and followed by a space.
+func writeCell(buf *bytes.Buffer, s string, width int, alignRight bool) {
+	pad := strings.Repeat(" ", width-utf8.RuneCountInString(s))
+	if alignRight {
+		buf.WriteString(pad)
+		buf.WriteString(s)
+	} else {
+		buf.WriteString(s)
+		buf.WriteString(pad)
+	}
+	buf.WriteByte(' ')
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return noun
+	}
+	return noun + "s"
+}
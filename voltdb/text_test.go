@@ -0,0 +1,65 @@
+package voltdb
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func textTestTable(t *testing.T) *Table {
+	return testBuilder(t, []string{"ID", "NAME", "WHEN"},
+		[]ColumnType{TypeInteger, TypeString, TypeTimestamp},
+		[]interface{}{1, "one", time.Date(2020, 1, 2, 3, 4, 5, 6000, time.UTC)},
+		[]interface{}{100, "a rather long name", nil}).Build()
+}
+
+func TestTableString(t *testing.T) {
+	table := textTestTable(t)
+	table.Next(new(struct{ ID int }))
+	expected := "" +
+		"ID  NAME               WHEN                       \n" +
+		"--- ------------------ -------------------------- \n" +
+		"  1 one                2020-01-02 03:04:05.000006 \n" +
+		"100 a rather long name NULL                       \n" +
+		"\n(Returned 2 rows)\n"
+	if s := table.String(); s != expected {
+		t.Errorf("Bad table text. Have\n%v", s)
+	}
+	if !table.HasNext() {
+		t.Errorf("String moved the read position")
+	}
+}
+
+func TestTableWriteTextOptions(t *testing.T) {
+	table := textTestTable(t)
+	var out strings.Builder
+	opts := TextOptions{MaxColumnWidth: 8, MaxRows: 1, Null: "-"}
+	if err := table.WriteText(&out, opts); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := "" +
+		"ID NAME WHEN     \n" +
+		"-- ---- -------- \n" +
+		" 1 one  2020-... \n" +
+		"... 1 more row\n" +
+		"\n(Returned 2 rows)\n"
+	if out.String() != expected {
+		t.Errorf("Bad table text. Have\n%v", out.String())
+	}
+}
+
+func TestResponseString(t *testing.T) {
+	table := textTestTable(t)
+	rsp := Response{status: int8(GRACEFUL_FAILURE), statusString: "bad things",
+		tables: []Table{*table, *table}}
+	s := rsp.String()
+	if !strings.HasPrefix(s, "GRACEFUL FAILURE: bad things\n") {
+		t.Errorf("Missing status. Have\n%v", s)
+	}
+	if strings.Count(s, "(Returned 2 rows)") != 2 {
+		t.Errorf("Expected both tables. Have\n%v", s)
+	}
+	if Status(-9).String() != "Status(-9)" {
+		t.Errorf("Bad unknown status. Have %v", Status(-9))
+	}
+}
@@ -0,0 +1,109 @@
+package voltdb
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ColumnType is the wire type of a column or parameter value.
+type ColumnType int8
+
+const (
+	TypeTinyInt   ColumnType = 3
+	TypeSmallInt  ColumnType = 4
+	TypeInteger   ColumnType = 5
+	TypeBigInt    ColumnType = 6
+	TypeFloat     ColumnType = 8
+	TypeString    ColumnType = 9
+	TypeTimestamp ColumnType = 11
+	TypeTable     ColumnType = 21
+	TypeDecimal   ColumnType = 22
+	TypeBoolean   ColumnType = 23
+	TypeVarbinary ColumnType = 25
+
+	TypeGeographyPoint ColumnType = 26
+	TypeGeography      ColumnType = 27
+)
+
+var columnTypeNames = map[ColumnType]string{
+	TypeTinyInt:   "TINYINT",
+	TypeSmallInt:  "SMALLINT",
+	TypeInteger:   "INTEGER",
+	TypeBigInt:    "BIGINT",
+	TypeFloat:     "FLOAT",
+	TypeString:    "VARCHAR",
+	TypeTimestamp: "TIMESTAMP",
+	TypeTable:     "TABLE",
+	TypeDecimal:   "DECIMAL",
+	TypeBoolean:   "BOOLEAN",
+	TypeVarbinary: "VARBINARY",
+
+	TypeGeographyPoint: "GEOGRAPHY_POINT",
+	TypeGeography:      "GEOGRAPHY",
+}
+
+// String returns the SQL name of the type.
+func (ct ColumnType) String() string {
+	if name, ok := columnTypeNames[ct]; ok {
+		return name
+	}
+	return fmt.Sprintf("ColumnType(%d)", int8(ct))
+}
+
+var columnGoTypes = map[ColumnType]reflect.Type{
+	TypeTinyInt:   reflect.TypeOf(int8(0)),
+	TypeSmallInt:  reflect.TypeOf(int16(0)),
+	TypeInteger:   reflect.TypeOf(int32(0)),
+	TypeBigInt:    reflect.TypeOf(int64(0)),
+	TypeFloat:     reflect.TypeOf(float64(0)),
+	TypeString:    reflect.TypeOf(""),
+	TypeTimestamp: reflect.TypeOf(time.Time{}),
+	TypeTable:     reflect.TypeOf((*Table)(nil)),
+	TypeDecimal:   reflect.TypeOf((*big.Rat)(nil)),
+	TypeBoolean:   reflect.TypeOf(false),
+	TypeVarbinary: reflect.TypeOf([]byte(nil)),
+
+	TypeGeographyPoint: reflect.TypeOf(GeographyPoint{}),
+	TypeGeography:      reflect.TypeOf(Geography{}),
+}
+
+// GoType returns the type of the values NextValues returns for columns
+// of the type, or nil for an unknown type.
+func (ct ColumnType) GoType() reflect.Type {
+	return columnGoTypes[ct]
+}
+
+// Column describes one column of a Table.
+type Column struct {
+	Name  string
+	Type  ColumnType
+	Index int
+}
+
+// Columns returns the table's columns in order.
+func (table *Table) Columns() []Column {
+	columns := make([]Column, len(table.columnTypes))
+	for idx, vt := range table.columnTypes {
+		columns[idx] = Column{table.columnNames[idx], ColumnType(vt), idx}
+	}
+	return columns
+}
+
+// ColumnIndex returns the index of the column named name, ignoring
+// case as Next does, or -1 if the table has no such column.
+func (table *Table) ColumnIndex(name string) int {
+	for idx, colName := range table.columnNames {
+		if colName == name {
+			return idx
+		}
+	}
+	for idx, colName := range table.columnNames {
+		if strings.EqualFold(colName, name) {
+			return idx
+		}
+	}
+	return -1
+}
@@ -0,0 +1,42 @@
+package voltdb
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestColumnTypeNames(t *testing.T) {
+	if TypeString.String() != "VARCHAR" || TypeBigInt.String() != "BIGINT" {
+		t.Errorf("Bad names %v %v", TypeString, TypeBigInt)
+	}
+	if ColumnType(99).String() != "ColumnType(99)" {
+		t.Errorf("Bad unknown type name %v", ColumnType(99))
+	}
+	if TypeDecimal.GoType() != reflect.TypeOf(new(big.Rat)) || ColumnType(99).GoType() != nil {
+		t.Errorf("Bad Go types %v %v", TypeDecimal.GoType(), ColumnType(99).GoType())
+	}
+}
+
+func TestTableColumns(t *testing.T) {
+	table := testTable([]string{"ID", "Name"}, []int8{vt_INT, vt_STRING},
+		[]interface{}{int32(1), "one"})
+	columns := table.Columns()
+	if len(columns) != 2 || columns[1] != (Column{"Name", TypeString, 1}) {
+		t.Errorf("Bad columns %v", columns)
+	}
+	if table.ColumnIndex("name") != 1 || table.ColumnIndex("ID") != 0 || table.ColumnIndex("x") != -1 {
+		t.Errorf("Bad column indexes")
+	}
+
+	// each column's value has the Go type of its ColumnType.
+	values, err := table.NextValues()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for idx, val := range values {
+		if reflect.TypeOf(val) != columns[idx].Type.GoType() {
+			t.Errorf("Column %v value %T, expected %v", columns[idx].Name, val, columns[idx].Type.GoType())
+		}
+	}
+}